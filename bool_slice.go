@@ -53,6 +53,11 @@ func (s *boolSliceValue) Set(val string) error {
 	return nil
 }
 
+func (s *boolSliceValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 // Type returns a string that uniquely represents this flag's type.
 func (s *boolSliceValue) Type() string {
 	return "boolSlice"
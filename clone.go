@@ -0,0 +1,240 @@
+package pflag
+
+import (
+	"fmt"
+	"net"
+)
+
+// CloneableValue is implemented by custom Values which can be copied, so that
+// flags using them can be cloned by FlagSet.Clone. The Values of this package
+// are all cloneable.
+type CloneableValue interface {
+	Value
+	// Clone returns a copy of the Value which can be set independently.
+	Clone() Value
+}
+
+// cloneValue returns a copy of v which can be set without affecting v, or
+// false if the type of v is unknown.
+func cloneValue(v Value) (Value, bool) {
+	switch v := v.(type) {
+	case CloneableValue:
+		return v.Clone(), true
+	case *boolValue:
+		c := *v
+		return &c, true
+	case *countValue:
+		c := *v
+		return &c, true
+	case *durationValue:
+		c := *v
+		return &c, true
+	case *float32Value:
+		c := *v
+		return &c, true
+	case *float64Value:
+		c := *v
+		return &c, true
+	case *intValue:
+		c := *v
+		return &c, true
+	case *int8Value:
+		c := *v
+		return &c, true
+	case *int32Value:
+		c := *v
+		return &c, true
+	case *int64Value:
+		c := *v
+		return &c, true
+	case *uintValue:
+		c := *v
+		return &c, true
+	case *uint8Value:
+		c := *v
+		return &c, true
+	case *uint16Value:
+		c := *v
+		return &c, true
+	case *uint32Value:
+		c := *v
+		return &c, true
+	case *uint64Value:
+		c := *v
+		return &c, true
+	case *stringValue:
+		c := *v
+		return &c, true
+	case *noArgumentValue:
+		c := *v
+		return &c, true
+	case *optionalArgumentValue:
+		c := *v
+		return &c, true
+	case *ipValue:
+		c := ipValue(append(net.IP(nil), *v...))
+		return &c, true
+	case *ipMaskValue:
+		c := ipMaskValue(append(net.IPMask(nil), *v...))
+		return &c, true
+	case *ipNetValue:
+		c := ipNetValue{
+			IP:   append(net.IP(nil), v.IP...),
+			Mask: append(net.IPMask(nil), v.Mask...),
+		}
+		return &c, true
+	case *pathValue:
+		s := *v.value
+		return &pathValue{value: &s, checks: v.checks}, true
+	case *boolSliceValue:
+		s := append([]bool(nil), *v.value...)
+		return &boolSliceValue{value: &s, changed: v.changed}, true
+	case *intSliceValue:
+		s := append([]int(nil), *v.value...)
+		return &intSliceValue{value: &s, changed: v.changed}, true
+	case *uintSliceValue:
+		s := append([]uint(nil), *v.value...)
+		return &uintSliceValue{value: &s, changed: v.changed}, true
+	case *ipSliceValue:
+		s := append([]net.IP(nil), *v.value...)
+		return &ipSliceValue{value: &s, changed: v.changed}, true
+	case *stringSliceValue:
+		s := append([]string(nil), *v.value...)
+		return &stringSliceValue{value: &s, changed: v.changed}, true
+	case *stringArrayValue:
+		s := append([]string(nil), *v.value...)
+		return &stringArrayValue{value: &s, changed: v.changed}, true
+	case *secretValue:
+		c, ok := cloneValue(v.Value)
+		if !ok {
+			return nil, false
+		}
+		return &secretValue{Value: c, defValue: v.defValue, fileFlag: v.fileFlag}, true
+	case *secretFileValue:
+		return &secretFileValue{target: v.target, path: v.path}, true
+	}
+	return nil, false
+}
+
+// Clone returns a deep copy of the FlagSet: its flags, with their
+// annotations, aliases, ranges and deprecations, its options and its parse
+// state. Values are copied, so that the clone and the original can be set and
+// parsed independently; variables bound to the flags of the original are not
+// updated by the clone, whose values are read with the Get methods.
+//
+// Functions, such as Usage, validators, observers and the normalization
+// function, are shared. An error is returned if the Value of a flag is
+// neither one of this package nor a CloneableValue.
+func (f *FlagSet) Clone() (*FlagSet, error) {
+	c := &FlagSet{
+		Usage:             f.Usage,
+		SortFlags:         f.SortFlags,
+		name:              f.name,
+		parsed:            f.parsed,
+		args:              append([]string(nil), f.args...),
+		argsLenAtDash:     f.argsLenAtDash,
+		errorHandling:     f.errorHandling,
+		output:            f.output,
+		interspersed:      f.interspersed,
+		multiLetter:       f.multiLetter,
+		singleDashLong:    f.singleDashLong,
+		getopt:            f.getopt,
+		passthrough:       f.passthrough,
+		unknownFlags:      append([]string(nil), f.unknownFlags...),
+		passthroughArgs:   append([]string(nil), f.passthroughArgs...),
+		normalizeNameFunc: f.normalizeNameFunc,
+		validateFunc:      f.validateFunc,
+		warningHandler:    f.warningHandler,
+		version:           f.version,
+		concurrent:        f.concurrent,
+		autoWidth:         f.autoWidth,
+		color:             f.color,
+	}
+
+	flags := make(map[*Flag]*Flag, len(f.formal))
+	for _, flag := range f.orderedFormal {
+		clone, err := cloneFlag(flag)
+		if err != nil {
+			return nil, err
+		}
+		flags[flag] = clone
+		c.orderedFormal = append(c.orderedFormal, clone)
+	}
+
+	if f.formal != nil {
+		c.formal = make(map[NormalizedName]*Flag, len(f.formal))
+		for name, flag := range f.formal {
+			c.formal[name] = flags[flag]
+		}
+	}
+	if f.actual != nil {
+		c.actual = make(map[NormalizedName]*Flag, len(f.actual))
+		for name, flag := range f.actual {
+			c.actual[name] = flags[flag]
+		}
+	}
+	for _, flag := range f.orderedActual {
+		c.orderedActual = append(c.orderedActual, flags[flag])
+	}
+	if f.aliases != nil {
+		c.aliases = make(map[NormalizedName]*Flag, len(f.aliases))
+		for name, flag := range f.aliases {
+			c.aliases[name] = flags[flag]
+		}
+	}
+	if f.shorthands != nil {
+		c.shorthands = make(map[rune]*Flag, len(f.shorthands))
+		for r, flag := range f.shorthands {
+			c.shorthands[r] = flags[flag]
+		}
+	}
+	if f.longShorthands != nil {
+		c.longShorthands = make(map[string]*Flag, len(f.longShorthands))
+		for name, flag := range f.longShorthands {
+			c.longShorthands[name] = flags[flag]
+		}
+	}
+	for _, p := range f.positionals {
+		c.positionals = append(c.positionals, &positional{flag: flags[p.flag], arity: p.arity})
+	}
+	c.groups = append([]string(nil), f.groups...)
+	c.usageTemplate = f.usageTemplate
+	c.observers = append(([]func(Change))(nil), f.observers...)
+	if f.flagObservers != nil {
+		c.flagObservers = make(map[NormalizedName][]func(Change), len(f.flagObservers))
+		for name, observers := range f.flagObservers {
+			c.flagObservers[name] = append(([]func(Change))(nil), observers...)
+		}
+	}
+	return c, nil
+}
+
+// cloneFlag returns a deep copy of flag.
+func cloneFlag(flag *Flag) (*Flag, error) {
+	clone := *flag
+
+	value, ok := cloneValue(flag.Value)
+	if !ok {
+		return nil, fmt.Errorf("flag %q has a value of type %T which can't be cloned", flag.Name, flag.Value)
+	}
+	clone.Value = value
+
+	if flag.Annotations != nil {
+		clone.Annotations = make(map[string][]string, len(flag.Annotations))
+		for key, values := range flag.Annotations {
+			clone.Annotations[key] = append([]string(nil), values...)
+		}
+	}
+	if flag.Aliases != nil {
+		clone.Aliases = append([]string(nil), flag.Aliases...)
+	}
+	if flag.Range != nil {
+		r := *flag.Range
+		clone.Range = &r
+	}
+	if flag.Deprecation != nil {
+		d := *flag.Deprecation
+		clone.Deprecation = &d
+	}
+	return &clone, nil
+}
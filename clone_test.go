@@ -0,0 +1,137 @@
+package pflag
+
+import (
+	goflag "flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type cloneableValue struct{ s *string }
+
+func (v cloneableValue) String() string     { return *v.s }
+func (v cloneableValue) Set(s string) error { *v.s = s; return nil }
+func (v cloneableValue) Type() string       { return "cloneable" }
+func (v cloneableValue) Clone() Value {
+	s := *v.s
+	return cloneableValue{&s}
+}
+
+func setUpTemplate() *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetWarningHandler(func(Warning) {})
+	f.BoolP("verbose", "v", false, "verbose")
+	f.IntSlice("port", []int{80}, "ports")
+	f.Int("workers", 1, "workers")
+	f.SetRange("workers", Range{Min: "1"})
+	f.SetAnnotation("port", "key", []string{"value"})
+	f.String("user", "", "user")
+	f.AddAlias("user", "login")
+	f.String("old-user", "", "user")
+	f.Deprecate("old-user", Deprecation{ReplacedBy: "user"})
+	f.String("token", "", "token")
+	f.AddSecretFileFlag("token")
+	s := "x"
+	f.Var(cloneableValue{&s}, "custom", "custom")
+	f.StringArray("file", []string{}, "files")
+	f.MarkPositional("file", ArgOptionalVariadic)
+	return f
+}
+
+func TestClone(t *testing.T) {
+	template := setUpTemplate()
+	a, err := template.Clone()
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	b, err := template.Clone()
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	tmp, err := ioutil.TempFile("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmp.Name())
+	tmp.WriteString("secret\n")
+	tmp.Close()
+
+	if err := a.Parse([]string{"-v", "--port=1,2", "--login=bob", "--custom=y", "--token-file", tmp.Name(), "f1"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := b.Parse([]string{"--old-user=alice", "--port=3", "f2", "f3"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	check := func(f *FlagSet, name string, expected string) {
+		if got := f.Lookup(name).Value.String(); got != expected {
+			t.Errorf("expected %s to be %q, got %q", name, expected, got)
+		}
+	}
+	check(a, "verbose", "true")
+	check(a, "port", "[1,2]")
+	check(a, "user", "bob")
+	check(a, "custom", "y")
+	check(a, "file", "[f1]")
+	if token, _ := a.GetString("token"); token != "secret" {
+		t.Errorf("expected the secret file flag to set the clone, got %q", token)
+	}
+	check(b, "verbose", "false")
+	check(b, "port", "[3]")
+	check(b, "user", "alice")
+	check(b, "custom", "x")
+	check(b, "file", "[f2,f3]")
+	if token, _ := b.GetString("token"); token != "" {
+		t.Errorf("expected the secret to be unset, got %q", token)
+	}
+	for _, name := range []string{"verbose", "port", "user", "custom", "file", "token"} {
+		if template.Lookup(name).Changed {
+			t.Errorf("expected %s to be unchanged in the template", name)
+		}
+	}
+	check(template, "custom", "x")
+
+	a.Lookup("port").Annotations["key"][0] = "changed"
+	if !reflect.DeepEqual(template.Lookup("port").Annotations["key"], []string{"value"}) {
+		t.Error("expected annotations to be copied")
+	}
+	if a.ShorthandLookup("v") != a.Lookup("verbose") {
+		t.Error("expected shorthands to point to the cloned flags")
+	}
+	if err := a.Set("workers", "0"); err == nil {
+		t.Error("expected the range to be cloned")
+	}
+}
+
+func TestCloneParsed(t *testing.T) {
+	f := setUpTemplate()
+	if err := f.Parse([]string{"--port=1", "f1"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	c, err := f.Clone()
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !c.Parsed() || c.NFlag() != f.NFlag() || !reflect.DeepEqual(c.Args(), f.Args()) || !c.Changed("port") {
+		t.Error("expected the parse state to be cloned")
+	}
+	if err := c.Set("port", "2"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if ports, _ := f.GetIntSlice("port"); !reflect.DeepEqual(ports, []int{1}) {
+		t.Errorf("expected the original to be unchanged, got %v", ports)
+	}
+}
+
+func TestCloneUnknownValue(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	gf := goflag.NewFlagSet("test", goflag.ContinueOnError)
+	gf.Int("go", 0, "go flag")
+	f.AddGoFlagSet(gf)
+	if _, err := f.Clone(); err == nil {
+		t.Error("expected an error for a value which can't be cloned")
+	}
+}
@@ -0,0 +1,213 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Command is a node of a command tree built on top of FlagSets, for
+// programs invoked as "tool [global flags] <command> [command flags] [args]".
+//
+// Each command owns a FlagSet. Flags defined on PersistentFlags are also
+// accepted by all the subcommands. When a command has subcommands, flag
+// parsing stops at the first non-flag argument, which names the subcommand to
+// run with the remaining arguments.
+type Command struct {
+	// Name is the name of the command on the command line.
+	Name string
+	// Short is the one-line description shown in the parent's usage message.
+	Short string
+	// Run is called with the non-flag arguments once the flags of the command
+	// have been parsed. A command without Run requires a subcommand.
+	Run func(cmd *Command, args []string) error
+
+	flags      *FlagSet
+	persistent *FlagSet
+	parent     *Command
+	commands   []*Command
+	output     io.Writer
+}
+
+// NewCommand returns a new command with the given name and description.
+func NewCommand(name, short string) *Command {
+	c := &Command{Name: name, Short: short}
+	c.flags = NewFlagSet(name, ContinueOnError)
+	c.flags.Usage = func() { fmt.Fprint(c.flags.out(), c.UsageString()) }
+	c.persistent = NewFlagSet(name, ContinueOnError)
+	return c
+}
+
+// Flags returns the FlagSet of the command. After Execute, it also holds the
+// persistent flags of the command and of its parents.
+func (c *Command) Flags() *FlagSet {
+	return c.flags
+}
+
+// PersistentFlags returns the flags of the command which are also accepted
+// by its subcommands.
+func (c *Command) PersistentFlags() *FlagSet {
+	return c.persistent
+}
+
+// AddCommand adds subcommands to the command.
+func (c *Command) AddCommand(cmds ...*Command) {
+	for _, cmd := range cmds {
+		if cmd == c {
+			panic("command can't be a child of itself")
+		}
+		cmd.parent = c
+		c.commands = append(c.commands, cmd)
+	}
+}
+
+// Commands returns the subcommands of the command.
+func (c *Command) Commands() []*Command {
+	return c.commands
+}
+
+// Parent returns the command this command was added to, or nil.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// SetOutput sets the destination for usage and error messages of the command
+// and its subcommands. If output is nil, os.Stderr is used.
+func (c *Command) SetOutput(output io.Writer) {
+	c.output = output
+}
+
+func (c *Command) out() io.Writer {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.output != nil {
+			return cmd.output
+		}
+	}
+	return c.flags.out()
+}
+
+// CommandPath returns the names of the command and its parents, separated by
+// spaces, e.g. "tool remote add".
+func (c *Command) CommandPath() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.CommandPath() + " " + c.Name
+}
+
+// inheritedFlags returns the persistent flags of the parents of the command.
+func (c *Command) inheritedFlags() *FlagSet {
+	inherited := NewFlagSet(c.Name, ContinueOnError)
+	for p := c.parent; p != nil; p = p.parent {
+		inherited.AddFlagSet(p.persistent)
+	}
+	return inherited
+}
+
+// localFlags returns the flags defined on the command itself.
+func (c *Command) localFlags() *FlagSet {
+	local := NewFlagSet(c.Name, ContinueOnError)
+	local.SortFlags = c.flags.SortFlags
+	local.AddFlagSet(c.persistent)
+	inherited := c.inheritedFlags()
+	c.flags.VisitAll(func(flag *Flag) {
+		if c.flags.positionalArg(flag) != nil {
+			return
+		}
+		if inherited.Lookup(flag.Name) == nil && local.Lookup(flag.Name) == nil {
+			local.AddFlag(flag)
+		}
+	})
+	return local
+}
+
+// findCommand returns the subcommand with the given name, or nil.
+func (c *Command) findCommand(name string) *Command {
+	for _, cmd := range c.commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// Execute parses args, which should not include the command name, and runs
+// the command or the subcommand named by the first non-flag argument.
+// Arguments after a "--" terminator are never taken as a subcommand name.
+func (c *Command) Execute(args []string) error {
+	c.flags.SetOutput(c.out())
+	c.flags.AddFlagSet(c.persistent)
+	c.flags.AddFlagSet(c.inheritedFlags())
+	if len(c.commands) > 0 {
+		c.flags.SetInterspersed(false)
+	}
+
+	if err := c.flags.Parse(args); err != nil {
+		return err
+	}
+	rest := c.flags.Args()
+
+	if len(c.commands) > 0 && len(rest) > 0 && c.flags.ArgsLenAtDash() != 0 {
+		if cmd := c.findCommand(rest[0]); cmd != nil {
+			return cmd.Execute(rest[1:])
+		}
+		if c.Run == nil {
+			err := fmt.Errorf("unknown command %q for %q", rest[0], c.CommandPath())
+			fmt.Fprintln(c.out(), err)
+			c.flags.usage()
+			return err
+		}
+	}
+	if c.Run == nil {
+		err := fmt.Errorf("%q requires a command", c.CommandPath())
+		fmt.Fprintln(c.out(), err)
+		c.flags.usage()
+		return err
+	}
+	return c.Run(c, rest)
+}
+
+// UsageString returns the usage message of the command: its synopsis, its
+// subcommands, its own flags and the flags inherited from its parents.
+func (c *Command) UsageString() string {
+	buf := new(bytes.Buffer)
+
+	synopsis := c.CommandPath() + " [flags]"
+	if len(c.commands) > 0 {
+		synopsis += " <command>"
+	}
+	for _, p := range c.flags.positionals {
+		synopsis += " " + p.synopsis()
+	}
+	fmt.Fprintf(buf, "Usage: %s\n", synopsis)
+
+	if len(c.flags.positionals) > 0 {
+		fmt.Fprintf(buf, "\nArguments:\n%s", c.flags.PositionalUsages())
+	}
+
+	if len(c.commands) > 0 {
+		fmt.Fprintf(buf, "\nCommands:\n")
+		maxlen := 0
+		for _, cmd := range c.commands {
+			if w := displayWidth(cmd.Name); w > maxlen {
+				maxlen = w
+			}
+		}
+		for _, cmd := range c.commands {
+			fmt.Fprintf(buf, "  %s%s   %s\n", cmd.Name, strings.Repeat(" ", maxlen-displayWidth(cmd.Name)), cmd.Short)
+		}
+	}
+
+	if local := c.localFlags(); local.HasAvailableFlags() {
+		fmt.Fprintf(buf, "\nFlags:\n%s", local.FlagUsages())
+	}
+	if inherited := c.inheritedFlags(); inherited.HasAvailableFlags() {
+		fmt.Fprintf(buf, "\nGlobal Flags:\n%s", inherited.FlagUsages())
+	}
+
+	if len(c.commands) > 0 {
+		fmt.Fprintf(buf, "\nRun '%s <command> --help' for more information on a command.\n", c.CommandPath())
+	}
+	return buf.String()
+}
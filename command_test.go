@@ -0,0 +1,156 @@
+package pflag
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setUpCommands() (root, serve *Command, ran *[]string) {
+	ran = new([]string)
+	root = NewCommand("tool", "a tool")
+	root.SetOutput(new(bytes.Buffer))
+	root.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	root.Flags().String("config", "", "config file")
+
+	serve = NewCommand("serve", "start the server")
+	serve.Flags().Int("port", 80, "port to listen on")
+	serve.Run = func(cmd *Command, args []string) error {
+		*ran = append(*ran, cmd.Name)
+		*ran = append(*ran, args...)
+		return nil
+	}
+	version := NewCommand("version", "print the version")
+	version.Run = func(cmd *Command, args []string) error {
+		*ran = append(*ran, cmd.Name)
+		return nil
+	}
+	root.AddCommand(serve, version)
+	return root, serve, ran
+}
+
+func TestCommandDispatch(t *testing.T) {
+	root, serve, ran := setUpCommands()
+
+	err := root.Execute([]string{"--config=tool.conf", "serve", "--port", "8080", "-v", "extra"})
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !reflect.DeepEqual(*ran, []string{"serve", "extra"}) {
+		t.Errorf("unexpected run %v", *ran)
+	}
+	if config, _ := root.Flags().GetString("config"); config != "tool.conf" {
+		t.Errorf("expected config to be parsed by root, got %q", config)
+	}
+	if port, _ := serve.Flags().GetInt("port"); port != 8080 {
+		t.Errorf("expected port 8080, got %d", port)
+	}
+	if verbose, _ := serve.Flags().GetBool("verbose"); !verbose {
+		t.Error("expected persistent flag to be accepted by the subcommand")
+	}
+	if !root.PersistentFlags().Changed("verbose") {
+		t.Error("expected persistent flag to be shared with the parent")
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	root, _, ran := setUpCommands()
+	out := new(bytes.Buffer)
+	root.SetOutput(out)
+
+	if err := root.Execute([]string{"deploy"}); err == nil || !strings.Contains(err.Error(), `unknown command "deploy"`) {
+		t.Errorf("expected unknown command error, got %v", err)
+	}
+	if err := root.Execute([]string{}); err == nil {
+		t.Error("expected an error without a command")
+	}
+	if err := root.Execute([]string{"--", "serve"}); err == nil {
+		t.Error("expected arguments after -- not to be taken as a command")
+	}
+	if err := root.Execute([]string{"version", "--port=1"}); err == nil {
+		t.Error("expected subcommand flags not to be accepted by other commands")
+	}
+	if len(*ran) != 0 {
+		t.Errorf("expected nothing to run, got %v", *ran)
+	}
+	if !strings.Contains(out.String(), "Usage: tool [flags] <command>") {
+		t.Errorf("expected usage to be printed, got:\n%s", out.String())
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	root, serve, _ := setUpCommands()
+	serve.Execute([]string{})
+
+	expected := `Usage: tool [flags] <command>
+
+Commands:
+  serve     start the server
+  version   print the version
+
+Flags:
+      --config string   config file
+  -v, --verbose         verbose output
+
+Run 'tool <command> --help' for more information on a command.
+`
+	if usage := root.UsageString(); usage != expected {
+		t.Errorf("expected root usage:\n%s\ngot:\n%s", expected, usage)
+	}
+
+	expected = `Usage: tool serve [flags]
+
+Flags:
+      --port int   port to listen on (default 80)
+
+Global Flags:
+  -v, --verbose   verbose output
+`
+	if usage := serve.UsageString(); usage != expected {
+		t.Errorf("expected serve usage:\n%s\ngot:\n%s", expected, usage)
+	}
+
+	out := new(bytes.Buffer)
+	root.SetOutput(out)
+	if err := root.Execute([]string{"serve", "--help"}); err != ErrHelp {
+		t.Errorf("expected ErrHelp, got %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected help to print serve usage, got:\n%s", out.String())
+	}
+}
+
+func TestCommandUsageUnicode(t *testing.T) {
+	root := NewCommand("tool", "a tool")
+	root.AddCommand(NewCommand("起動", "start the server"), NewCommand("version", "print the version"))
+
+	expected := `Commands:
+  起動      start the server
+  version   print the version
+`
+	if usage := root.UsageString(); !strings.Contains(usage, expected) {
+		t.Errorf("expected aligned commands:\n%s\ngot:\n%s", expected, usage)
+	}
+}
+
+func TestCommandPositionals(t *testing.T) {
+	root, serve, _ := setUpCommands()
+	serve.Flags().String("addr", "", "address to listen on")
+	serve.Flags().MarkPositional("addr", ArgOptional)
+
+	if err := root.Execute([]string{"serve", "localhost:80"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if addr, _ := serve.Flags().GetString("addr"); addr != "localhost:80" {
+		t.Errorf("expected addr to be set, got %q", addr)
+	}
+
+	usage := serve.UsageString()
+	if !strings.HasPrefix(usage, "Usage: tool serve [flags] [ADDR]\n\nArguments:\n  ADDR   address to listen on\n") {
+		t.Errorf("unexpected usage:\n%s", usage)
+	}
+	if strings.Contains(usage, "--addr") {
+		t.Errorf("expected positional argument not to be listed as a flag:\n%s", usage)
+	}
+}
@@ -0,0 +1,51 @@
+package pflag
+
+// SetConcurrencySafe sets whether the FlagSet may be used by several
+// goroutines at once, for instance to change the verbosity of a running
+// service while it reads its flags. In this mode Set, SetWithSource, Lookup,
+// Changed, NFlag, Visit, VisitAll, Reset, Snapshot, Restore and the typed
+// getters, such as GetInt, are safe for concurrent use.
+//
+// Values must then be read with the typed getters rather than through the
+// variables bound to the flags or the Value of a Flag. Flags should be
+// defined and parsed before the FlagSet is shared, and the mode enabled
+// before that. Values and validators are called with the lock of the FlagSet
+// held and must not call its methods.
+func (f *FlagSet) SetConcurrencySafe(enabled bool) {
+	f.concurrent = enabled
+}
+
+// lock locks the FlagSet for writing in concurrency-safe mode and returns the
+// function unlocking it.
+func (f *FlagSet) lock() (unlock func()) {
+	if !f.concurrent {
+		return func() {}
+	}
+	f.mu.Lock()
+	return f.mu.Unlock
+}
+
+// rlock locks the FlagSet for reading in concurrency-safe mode and returns
+// the function unlocking it.
+func (f *FlagSet) rlock() (unlock func()) {
+	if !f.concurrent {
+		return func() {}
+	}
+	f.mu.RLock()
+	return f.mu.RUnlock
+}
+
+// lockedFlags returns the flags to visit, the set ones if actual is true,
+// without updating the sorted caches which would need the write lock.
+func (f *FlagSet) lockedFlags(actual bool) []*Flag {
+	defer f.rlock()()
+	switch {
+	case actual && f.SortFlags:
+		return sortFlags(f.actual)
+	case actual:
+		return append([]*Flag(nil), f.orderedActual...)
+	case f.SortFlags:
+		return sortFlags(f.formal)
+	}
+	return append([]*Flag(nil), f.orderedFormal...)
+}
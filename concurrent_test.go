@@ -0,0 +1,85 @@
+package pflag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+// Run with -race to check the concurrency-safe mode.
+func TestConcurrencySafe(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetConcurrencySafe(true)
+	f.Int("v", 0, "verbosity")
+	f.StringSlice("hosts", []string{}, "hosts")
+	f.String("old-v", "", "verbosity")
+	f.Deprecate("old-v", Deprecation{ReplacedBy: "v"})
+	f.SetWarningHandler(func(w Warning) {
+		// Warnings are issued without holding the lock.
+		f.Lookup(w.Flag.Name)
+	})
+	if err := f.Parse([]string{"--v=1"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := f.Set("v", fmt.Sprint(j)); err != nil {
+					t.Error(err)
+				}
+				f.Set("hosts", fmt.Sprintf("host%d", i))
+				f.Set("old-v", "7")
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := f.GetInt("v"); err != nil {
+					t.Error(err)
+				}
+				f.GetStringSlice("hosts")
+				f.Changed("v")
+				f.Lookup("hosts")
+				f.NFlag()
+				f.Visit(func(flag *Flag) { f.GetString(flag.Name) })
+				f.VisitAll(func(flag *Flag) { f.Changed(flag.Name) })
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hosts, _ := f.GetStringSlice("hosts"); len(hosts) != 400 {
+		t.Errorf("expected every update to be applied, got %d hosts", len(hosts))
+	}
+}
+
+func TestConcurrencySafeSnapshot(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetConcurrencySafe(true)
+	f.Int("v", 0, "verbosity")
+	s := f.Snapshot()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			f.Set("v", "1")
+			f.Restore(s)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			f.GetInt("v")
+			f.Reset()
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,166 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now is the clock used to check Deprecation.ErrorAfter.
+var now = time.Now
+
+// Deprecation describes the lifecycle of a deprecated flag.
+type Deprecation struct {
+	// ReplacedBy is the name of the flag replacing the deprecated one. Values
+	// given to the deprecated flag are forwarded to it.
+	ReplacedBy string
+	// RemovedIn is the version in which the flag is removed. Once the version
+	// set with SetVersion reaches it, using the flag is an error.
+	RemovedIn string
+	// ErrorAfter, if not zero, is the time after which using the flag is an
+	// error.
+	ErrorAfter time.Time
+	// Message is an additional explanation shown to users.
+	Message string
+}
+
+// String describes the deprecation, e.g. "use --new-name instead, it will be
+// removed in v3".
+func (d *Deprecation) String() string {
+	var parts []string
+	if d.ReplacedBy != "" {
+		parts = append(parts, fmt.Sprintf("use --%s instead", d.ReplacedBy))
+	}
+	if d.RemovedIn != "" {
+		parts = append(parts, fmt.Sprintf("it will be removed in %s", d.RemovedIn))
+	}
+	if d.Message != "" {
+		parts = append(parts, d.Message)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Deprecate marks a flag deprecated with the given lifecycle. Like
+// MarkDeprecated, the flag is hidden from the flags listed in usage messages
+// and a warning is issued when it is used; it is listed in the deprecated
+// flags section of the default usage message instead.
+func (f *FlagSet) Deprecate(name string, d Deprecation) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	if d.ReplacedBy != "" {
+		replacement := f.Lookup(d.ReplacedBy)
+		if replacement == nil {
+			return fmt.Errorf("replacement flag %q for flag %q does not exist", d.ReplacedBy, name)
+		}
+		if replacement == flag {
+			return fmt.Errorf("flag %q can't be replaced by itself", name)
+		}
+		d.ReplacedBy = replacement.Name
+		// Values are forwarded along the replacements, which must end.
+		for next := replacement; next != nil; {
+			if next == flag {
+				return fmt.Errorf("flag %q can't be replaced by flag %q, which is replaced by it", name, d.ReplacedBy)
+			}
+			if next.Deprecation == nil || next.Deprecation.ReplacedBy == "" {
+				break
+			}
+			next = f.Lookup(next.Deprecation.ReplacedBy)
+		}
+	}
+	if d.String() == "" {
+		return fmt.Errorf("deprecation of flag %q must name a replacement, a version or a message", name)
+	}
+	flag.Deprecated = d.String()
+	flag.Deprecation = &d
+	return nil
+}
+
+// SetVersion sets the version of the program, which is compared to
+// Deprecation.RemovedIn.
+func (f *FlagSet) SetVersion(version string) {
+	f.version = version
+}
+
+// checkRemoved returns an error if the flag may no longer be used.
+func (f *FlagSet) checkRemoved(flag *Flag) error {
+	d := flag.Deprecation
+	if d == nil {
+		return nil
+	}
+	removed := d.RemovedIn != "" && f.version != "" && compareVersions(f.version, d.RemovedIn) >= 0
+	if !removed && !d.ErrorAfter.IsZero() && now().After(d.ErrorAfter) {
+		removed = true
+	}
+	if !removed {
+		return nil
+	}
+	msg := fmt.Sprintf("flag --%s has been removed", flag.Name)
+	if d.RemovedIn != "" {
+		msg += " in " + d.RemovedIn
+	}
+	if d.ReplacedBy != "" {
+		msg += fmt.Sprintf(", use --%s instead", d.ReplacedBy)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// compareVersions compares two dotted versions such as "v1.2.3" field by
+// field, numerically when both fields are numbers. It returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		// Missing fields compare as zero, so that v3 == v3.0.
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		numeric := xerr == nil && yerr == nil
+		switch {
+		case numeric && xn < yn:
+			return -1
+		case numeric && xn > yn:
+			return 1
+		case numeric:
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// DeprecatedFlagUsages returns a string listing the flags deprecated with
+// Deprecate and what to do about them. Hidden flags are not listed.
+func (f *FlagSet) DeprecatedFlagUsages() string {
+	buf := new(bytes.Buffer)
+
+	var names, messages []string
+	maxlen := 0
+	f.VisitAll(func(flag *Flag) {
+		if flag.Deprecation == nil || flag.Hidden {
+			return
+		}
+		name := fmt.Sprintf("      --%s", flag.Name)
+		if w := displayWidth(name); w > maxlen {
+			maxlen = w
+		}
+		names = append(names, name)
+		messages = append(messages, flag.Deprecation.String())
+	})
+
+	for i, name := range names {
+		fmt.Fprintln(buf, name, strings.Repeat(" ", maxlen-displayWidth(name)+1), messages[i])
+	}
+	return buf.String()
+}
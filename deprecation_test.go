@@ -0,0 +1,166 @@
+package pflag
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setUpDeprecation(d Deprecation) (*FlagSet, *string, *string) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	oldName := f.String("old-name", "", "the old name")
+	newName := f.String("new-name", "", "the new name")
+	f.Deprecate("old-name", d)
+	return f, oldName, newName
+}
+
+func TestDeprecateForwards(t *testing.T) {
+	f, oldName, newName := setUpDeprecation(Deprecation{ReplacedBy: "new-name", RemovedIn: "v3"})
+
+	var warnings []Warning
+	f.SetWarningHandler(func(w Warning) { warnings = append(warnings, w) })
+
+	if err := f.Parse([]string{"--old-name=value"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *oldName != "value" || *newName != "value" {
+		t.Errorf("expected value to be forwarded, got %q and %q", *oldName, *newName)
+	}
+	if !f.Changed("new-name") {
+		t.Error("expected replacement flag to be changed")
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning, got %v", warnings)
+	}
+	w := warnings[0]
+	if w.Kind != WarnDeprecated || w.Flag.Name != "old-name" || w.Flag.Deprecation.ReplacedBy != "new-name" {
+		t.Errorf("unexpected warning %+v", w)
+	}
+	expected := "Flag --old-name has been deprecated, use --new-name instead, it will be removed in v3"
+	if w.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, w.Message)
+	}
+}
+
+func TestDeprecateRemovedInVersion(t *testing.T) {
+	testCases := []struct {
+		version string
+		success bool
+	}{
+		{"", true},
+		{"v2.9.1", true},
+		{"v3", false},
+		{"v3.0.1", false},
+		{"v10.0", false},
+	}
+
+	for _, tc := range testCases {
+		f, _, _ := setUpDeprecation(Deprecation{ReplacedBy: "new-name", RemovedIn: "v3"})
+		f.SetVersion(tc.version)
+		err := f.Parse([]string{"--old-name=value"})
+		if err != nil && tc.success {
+			t.Errorf("expected success for version %q, got %q", tc.version, err)
+		} else if err == nil && !tc.success {
+			t.Errorf("expected failure for version %q", tc.version)
+		} else if err != nil && err.Error() != "flag --old-name has been removed in v3, use --new-name instead" {
+			t.Errorf("unexpected error %q", err)
+		}
+	}
+}
+
+func TestDeprecateErrorAfter(t *testing.T) {
+	defer func() { now = time.Now }()
+	cutoff := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f, _, _ := setUpDeprecation(Deprecation{ReplacedBy: "new-name", ErrorAfter: cutoff})
+
+	now = func() time.Time { return cutoff.Add(-time.Hour) }
+	if err := f.Set("old-name", "value"); err != nil {
+		t.Error("expected no error before the cutoff; got", err)
+	}
+	now = func() time.Time { return cutoff.Add(time.Hour) }
+	if err := f.Set("old-name", "value"); err == nil {
+		t.Error("expected an error after the cutoff")
+	}
+}
+
+func TestDeprecateErrors(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("old-name", "", "the old name")
+
+	if err := f.Deprecate("missing", Deprecation{Message: "gone"}); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+	if err := f.Deprecate("old-name", Deprecation{ReplacedBy: "missing"}); err == nil {
+		t.Error("expected an error for a missing replacement")
+	}
+	if err := f.Deprecate("old-name", Deprecation{}); err == nil {
+		t.Error("expected an error for an empty deprecation")
+	}
+	if err := f.Deprecate("old-name", Deprecation{ReplacedBy: "old-name"}); err == nil {
+		t.Error("expected an error for a flag replacing itself")
+	}
+
+	f.String("a", "", "a")
+	f.String("b", "", "b")
+	f.String("c", "", "c")
+	if err := f.Deprecate("a", Deprecation{ReplacedBy: "b"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.Deprecate("b", Deprecation{ReplacedBy: "c"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.Deprecate("c", Deprecation{ReplacedBy: "a"}); err == nil {
+		t.Error("expected an error for a cycle of replacements")
+	}
+	if err := f.Deprecate("b", Deprecation{ReplacedBy: "a"}); err == nil {
+		t.Error("expected an error for a flag replaced by the flag it replaces")
+	}
+	f.SetWarningHandler(func(Warning) {})
+	if err := f.Set("a", "x"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if c, _ := f.GetString("c"); c != "x" {
+		t.Errorf("expected the value to be forwarded to c, got %q", c)
+	}
+}
+
+func TestDeprecatedFlagsSection(t *testing.T) {
+	f, _, _ := setUpDeprecation(Deprecation{ReplacedBy: "new-name", RemovedIn: "v3"})
+	f.Bool("legacy", false, "legacy mode")
+	f.MarkDeprecated("legacy", "it does nothing")
+	out := new(bytes.Buffer)
+	f.SetOutput(out)
+
+	f.usage()
+	usage := out.String()
+	if !strings.Contains(usage, "Deprecated flags:\n      --old-name   use --new-name instead, it will be removed in v3\n") {
+		t.Errorf("expected deprecated flags section, got:\n%s", usage)
+	}
+	if strings.Contains(usage, "old-name string") {
+		t.Errorf("expected deprecated flag to be hidden from the flag list, got:\n%s", usage)
+	}
+	if strings.Contains(usage, "legacy") {
+		t.Errorf("expected flag deprecated with MarkDeprecated to stay hidden, got:\n%s", usage)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v3", "v3.0", 0},
+		{"1.10", "1.9", 1},
+		{"v1.2", "v1.10", -1},
+		{"v2.0.0-rc1", "v2.0.0-rc2", -1},
+	}
+	for _, tc := range testCases {
+		if c := compareVersions(tc.a, tc.b); c != tc.expected {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tc.a, tc.b, c, tc.expected)
+		}
+	}
+}
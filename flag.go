@@ -106,6 +106,9 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
 )
 
 // ErrHelp is the error returned if the flag -help is invoked but no such flag is defined.
@@ -146,13 +149,35 @@ type FlagSet struct {
 	formal            map[NormalizedName]*Flag
 	orderedFormal     []*Flag
 	sortedFormal      []*Flag
-	shorthands        map[byte]*Flag
+	aliases           map[NormalizedName]*Flag
+	shorthands        map[rune]*Flag
+	longShorthands    map[string]*Flag // multi-letter shorthands, see SetMultiLetterShorthands
 	args              []string // arguments after flags
 	argsLenAtDash     int      // len(args) when a '--' was located when parsing, or -1 if no --
 	errorHandling     ErrorHandling
 	output            io.Writer // nil means stderr; use out() accessor
 	interspersed      bool      // allow interspersed option/non-option args
+	multiLetter       bool      // allow multi-letter shorthands, without clustering
+	singleDashLong    bool      // allow long flags after a single dash, like the flag package
+	getopt            bool      // glibc getopt_long behavior, see NewGetoptFlagSet
+	concurrent        bool      // guard reads and updates with mu, see SetConcurrencySafe
+	mu                sync.RWMutex
+	passthrough       bool      // collect unknown flags instead of failing
+	unknownFlags      []string  // unknown flags and their values, see UnknownFlags
+	passthroughArgs   []string  // unknown flags and non-flag arguments, see PassthroughArgs
+	parseSource       *Source   // source of the values set while parsing, nil otherwise
+	argIndexes        []int     // index in the parsed arguments of each of args
+	autoWidth         bool      // wrap usages to the terminal, see SetAutoWidth
+	color             bool      // colorize usages on terminals, see SetColor
 	normalizeNameFunc func(f *FlagSet, name string) NormalizedName
+	validateFunc      func(f *FlagSet) error
+	warningHandler    func(w Warning)
+	version           string
+	positionals       []*positional
+	observers         []func(Change)
+	groups            []string // titles of the flag groups, in order
+	usageTemplate     *template.Template
+	flagObservers     map[NormalizedName][]func(Change)
 }
 
 // A Flag represents the state of a flag.
@@ -168,6 +193,15 @@ type Flag struct {
 	Hidden              bool                // used by cobra.Command to allow flags to be hidden from help/usage text
 	ShorthandDeprecated string              // If the shorthand of this flag is deprecated, this string is the new or now thing to use
 	Annotations         map[string][]string // used by cobra.Command bash autocomple code
+	Range               *Range              // If set, the bounds the value must lie within
+	Validator           func(Value) error   // If set, called to check the value after it has been set
+	Secret              bool                // If true, the value is masked in usage messages and by Value.String; set by MarkSecret, or before AddFlag
+	Aliases             []string            // other long names of the flag
+	Deprecation         *Deprecation        // If set, the lifecycle of the deprecated flag
+	Greedy              bool                // If true, a flag with NoOptDefVal may take its value from the next argument
+	Source              Source              // where the value came from
+	Mutable             bool                // If true, the flag may be changed at runtime through NewHandler
+	Group               string              // title of the section listing the flag in usage messages, see AddGroup
 }
 
 // Value is the interface to the dynamic value stored in a flag.
@@ -209,6 +243,17 @@ func (f *FlagSet) SetNormalizeFunc(n func(f *FlagSet, name string) NormalizedNam
 		f.formal[nname] = v
 		f.orderedFormal[k] = v
 	}
+	f.aliases = nil
+	for _, v := range f.orderedFormal {
+		for i, alias := range v.Aliases {
+			nname := f.normalizeFlagName(alias)
+			v.Aliases[i] = string(nname)
+			if f.aliases == nil {
+				f.aliases = make(map[NormalizedName]*Flag)
+			}
+			f.aliases[nname] = v
+		}
+	}
 }
 
 // GetNormalizeFunc returns the previously set NormalizeFunc of a function which
@@ -242,6 +287,12 @@ func (f *FlagSet) SetOutput(output io.Writer) {
 // in primordial order if f.SortFlags is false, calling fn for each.
 // It visits all flags, even those not set.
 func (f *FlagSet) VisitAll(fn func(*Flag)) {
+	if f.concurrent {
+		for _, flag := range f.lockedFlags(false) {
+			fn(flag)
+		}
+		return
+	}
 	if len(f.formal) == 0 {
 		return
 	}
@@ -270,7 +321,7 @@ func (f *FlagSet) HasFlags() bool {
 // definied that are not hidden or deprecated.
 func (f *FlagSet) HasAvailableFlags() bool {
 	for _, flag := range f.formal {
-		if !flag.Hidden && len(flag.Deprecated) == 0 {
+		if !flag.Hidden && len(flag.Deprecated) == 0 && f.positionalArg(flag) == nil {
 			return true
 		}
 	}
@@ -288,6 +339,12 @@ func VisitAll(fn func(*Flag)) {
 // in primordial order if f.SortFlags is false, calling fn for each.
 // It visits only those flags that have been set.
 func (f *FlagSet) Visit(fn func(*Flag)) {
+	if f.concurrent {
+		for _, flag := range f.lockedFlags(true) {
+			fn(flag)
+		}
+		return
+	}
 	if len(f.actual) == 0 {
 		return
 	}
@@ -316,33 +373,61 @@ func Visit(fn func(*Flag)) {
 
 // Lookup returns the Flag structure of the named flag, returning nil if none exists.
 func (f *FlagSet) Lookup(name string) *Flag {
+	defer f.rlock()()
 	return f.lookup(f.normalizeFlagName(name))
 }
 
 // ShorthandLookup returns the Flag structure of the short handed flag,
 // returning nil if none exists.
-// It panics, if len(name) > 1.
+// It panics, if name is more than one character and multi-letter shorthands
+// are not enabled.
 func (f *FlagSet) ShorthandLookup(name string) *Flag {
 	if name == "" {
 		return nil
 	}
-	if len(name) > 1 {
-		msg := fmt.Sprintf("can not look up shorthand which is more than one ASCII character: %q", name)
+	if utf8.RuneCountInString(name) > 1 {
+		if f.multiLetter {
+			return f.longShorthands[name]
+		}
+		msg := fmt.Sprintf("can not look up shorthand which is more than one character: %q", name)
 		fmt.Fprintf(f.out(), msg)
 		panic(msg)
 	}
-	c := name[0]
+	c, _ := utf8.DecodeRuneInString(name)
 	return f.shorthands[c]
 }
 
-// lookup returns the Flag structure of the named flag, returning nil if none exists.
+// SetSingleDashLongFlags sets whether long flags may also be given after a
+// single dash, as with Go's flag package: "-verbose" and "-port=80" are then
+// the same as "--verbose" and "--port=80". This eases migrating callers of
+// programs which used the flag package. An argument like "-vp" is still parsed
+// as shorthands unless a long flag named "vp" exists, and "-v" names the
+// shorthand v when there is one, else the long flag named "v".
+func (f *FlagSet) SetSingleDashLongFlags(enabled bool) {
+	f.singleDashLong = enabled
+}
+
+// SetMultiLetterShorthands sets whether shorthands may be longer than one
+// character, like find's -name. Shorthands can then no longer be clustered:
+// the whole argument after a single dash, up to an optional "=value", names
+// one shorthand. It must be enabled before defining multi-letter shorthands.
+func (f *FlagSet) SetMultiLetterShorthands(enabled bool) {
+	f.multiLetter = enabled
+}
+
+// lookup returns the Flag structure of the named flag or alias, returning nil
+// if none exists.
 func (f *FlagSet) lookup(name NormalizedName) *Flag {
-	return f.formal[name]
+	if flag, ok := f.formal[name]; ok {
+		return flag
+	}
+	return f.aliases[name]
 }
 
 // func to return a given type for a given flag name
 func (f *FlagSet) getFlagType(name string, ftype string, convFunc func(sval string) (interface{}, error)) (interface{}, error) {
-	flag := f.Lookup(name)
+	defer f.rlock()()
+	flag := f.lookup(f.normalizeFlagName(name))
 	if flag == nil {
 		err := fmt.Errorf("flag accessed but not defined: %s", name)
 		return nil, err
@@ -353,7 +438,7 @@ func (f *FlagSet) getFlagType(name string, ftype string, convFunc func(sval stri
 		return nil, err
 	}
 
-	sval := flag.Value.String()
+	sval := revealString(flag.Value)
 	result, err := convFunc(sval)
 	if err != nil {
 		return nil, err
@@ -409,6 +494,36 @@ func (f *FlagSet) MarkHidden(name string) error {
 	return nil
 }
 
+// MarkGreedy makes a flag with a NoOptDefVal take the next argument as its
+// value when the argument does not start with a dash and is a valid value for
+// the flag, so that "--color", "--color always" and "--color=always" all work.
+// Otherwise the next argument is left alone and the flag gets its NoOptDefVal.
+// Flags of custom Value types must implement CloneableValue to be greedy.
+func (f *FlagSet) MarkGreedy(name string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	flag.Greedy = true
+	return nil
+}
+
+// accepts reports whether arg can be taken as the value of a greedy flag. The
+// argument is set on a copy of the value, so that the flag is left unchanged;
+// values which can't be copied never take the next argument.
+func (f *Flag) accepts(arg string) bool {
+	if !f.Greedy || strings.HasPrefix(arg, "-") {
+		return false
+	}
+	v, ok := cloneValue(f.Value)
+	if !ok {
+		return false
+	}
+	trial := *f
+	trial.Value = v
+	return trial.Value.Set(arg) == nil && trial.validate() == nil
+}
+
 // Lookup returns the Flag structure of the named command-line flag,
 // returning nil if none exists.
 func Lookup(name string) *Flag {
@@ -421,23 +536,79 @@ func ShorthandLookup(name string) *Flag {
 	return CommandLine.ShorthandLookup(name)
 }
 
-// Set sets the value of the named flag.
+// Set sets the value of the named flag. Its Source records that it was set by
+// the program, or on the command line when called while parsing.
 func (f *FlagSet) Set(name, value string) error {
-	normalName := f.normalizeFlagName(name)
-	flag, ok := f.formal[normalName]
-	if !ok {
-		return fmt.Errorf("no such flag -%v", name)
+	src := Source{Kind: SourceProgram}
+	if f.parseSource != nil {
+		src = *f.parseSource
+	}
+	return f.SetWithSource(name, value, src)
+}
+
+// SetWithSource sets the value of the named flag and records where the value
+// came from, for instance a configuration file or an environment variable.
+func (f *FlagSet) SetWithSource(name, value string, src Source) error {
+	flag, change, then, err := f.set(name, value, src)
+	if err != nil {
+		return err
+	}
+
+	if flag.Deprecated != "" {
+		f.warn(Warning{
+			Kind:    WarnDeprecated,
+			Flag:    flag,
+			Message: fmt.Sprintf("Flag --%s has been deprecated, %s", flag.Name, flag.Deprecated),
+		})
+	}
+	f.notify(change)
+	if then != nil {
+		return then()
+	}
+	return nil
+}
+
+// set sets the value of the named flag and records it as set. It returns the
+// flag, the change for observers and, if the value must also be given to
+// another flag, a function doing it. Observers and the function are called by
+// SetWithSource without holding the lock of the FlagSet.
+func (f *FlagSet) set(name, value string, src Source) (flag *Flag, change Change, then func() error, err error) {
+	defer f.lock()()
+
+	flag = f.lookup(f.normalizeFlagName(name))
+	if flag == nil {
+		return nil, change, nil, fmt.Errorf("no such flag -%v", name)
 	}
+	normalName := NormalizedName(flag.Name)
 
-	err := flag.Value.Set(value)
+	if err := f.checkRemoved(flag); err != nil {
+		return nil, change, nil, err
+	}
+
+	old := flag.Value.String()
+	restore := saveValue(flag.Value)
+	err = flag.Value.Set(value)
+	if err == nil {
+		err = flag.validate()
+	}
 	if err != nil {
+		// Some Values are left modified by a malformed argument.
+		restore()
 		var flagName string
 		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
 			flagName = fmt.Sprintf("-%s, --%s", flag.Shorthand, flag.Name)
 		} else {
 			flagName = fmt.Sprintf("--%s", flag.Name)
 		}
-		return fmt.Errorf("invalid argument %q for %q flag: %v", value, flagName, err)
+		if flag.Secret && value != "" {
+			// The parse error of the value may quote it too.
+			err = errors.New(strings.Replace(err.Error(), value, secretMask, -1))
+			value = secretMask
+		}
+		if p := f.positionalArg(flag); p != nil {
+			return nil, change, nil, fmt.Errorf("invalid argument %q for %s: %v", value, p.name(), err)
+		}
+		return nil, change, nil, fmt.Errorf("invalid argument %q for %q flag: %v", value, flagName, err)
 	}
 
 	if f.actual == nil {
@@ -447,20 +618,133 @@ func (f *FlagSet) Set(name, value string) error {
 	f.orderedActual = append(f.orderedActual, flag)
 
 	flag.Changed = true
+	if flag.Secret {
+		src = maskSource(src, value)
+	}
+	flag.Source = src
 
-	if flag.Deprecated != "" {
-		fmt.Fprintf(f.out(), "Flag --%s has been deprecated, %s\n", flag.Name, flag.Deprecated)
+	if v, ok := flag.Value.(*secretFileValue); ok {
+		target, content := v.target, v.content
+		v.content = ""
+		then = func() error { return f.SetWithSource(target, content, src) }
+	} else if flag.Deprecation != nil && flag.Deprecation.ReplacedBy != "" {
+		replacement := flag.Deprecation.ReplacedBy
+		then = func() error { return f.SetWithSource(replacement, value, src) }
+	}
+	change = Change{Flag: flag, Old: old, New: flag.Value.String(), Source: src}
+	return flag, change, then, nil
+}
+
+// WarningKind tells what a Warning is about.
+type WarningKind int
+
+const (
+	// WarnDeprecated is issued when a deprecated flag is used.
+	WarnDeprecated WarningKind = iota
+	// WarnShorthandDeprecated is issued when a deprecated shorthand is used.
+	WarnShorthandDeprecated
+	// WarnSecret is issued when a secret flag is passed on the command line.
+	WarnSecret
+)
+
+// A Warning is a problem with the use of a flag that does not prevent parsing.
+type Warning struct {
+	Kind    WarningKind
+	Flag    *Flag
+	Message string
+}
+
+// SetWarningHandler sets the function called for each warning issued while
+// setting flags. By default warnings are printed to the output of the
+// FlagSet. A nil handler restores the default.
+func (f *FlagSet) SetWarningHandler(handler func(w Warning)) {
+	f.warningHandler = handler
+}
+
+func (f *FlagSet) warn(w Warning) {
+	if f.warningHandler != nil {
+		f.warningHandler(w)
+		return
+	}
+	fmt.Fprintln(f.out(), w.Message)
+}
+
+// validate checks the value of the flag after it has been set.
+func (f *Flag) validate() error {
+	// Checks see the actual value of secret flags.
+	v := unwrapValue(f.Value)
+	if f.Range != nil {
+		if err := f.Range.check(v); err != nil {
+			return err
+		}
+	}
+	if f.Validator != nil {
+		return f.Validator(v)
 	}
 	return nil
 }
 
+// valueSaver is implemented by values whose String form cannot be passed back
+// to Set to restore them, such as slices which accumulate. save returns a
+// function restoring the current value, along with its changed state.
+type valueSaver interface {
+	save() (restore func())
+}
+
+// saveValue returns a function restoring v to its current state. Values of
+// custom types are left as they are: passing their String form back to Set
+// may not restore them.
+func saveValue(v Value) (restore func()) {
+	switch v := v.(type) {
+	case valueSaver:
+		return v.save()
+	case *countValue:
+		old := *v
+		return func() { *v = old }
+	case *ipValue:
+		old := *v
+		return func() { *v = old }
+	case *ipMaskValue:
+		old := *v
+		return func() { *v = old }
+	case *ipNetValue:
+		old := *v
+		return func() { *v = old }
+	case *boolValue, *durationValue, *float32Value, *float64Value,
+		*intValue, *int8Value, *int32Value, *int64Value,
+		*uintValue, *uint8Value, *uint16Value, *uint32Value, *uint64Value,
+		*stringValue, *optionalArgumentValue:
+		old := v.String()
+		return func() { v.Set(old) }
+	}
+	return func() {}
+}
+
+// SetValidator sets a function that checks the value of the named flag each
+// time it is set. A non-nil error rejects the argument: the previous value is
+// restored and the error is reported like a malformed argument.
+func (f *FlagSet) SetValidator(name string, fn func(Value) error) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	flag.Validator = fn
+	return nil
+}
+
+// SetValidateFunc sets a function that is called with the whole FlagSet once
+// Parse or ParseAll has processed all arguments successfully. It allows rules
+// involving several flags, such as flags that are mutually exclusive.
+func (f *FlagSet) SetValidateFunc(fn func(f *FlagSet) error) {
+	f.validateFunc = fn
+}
+
 // SetAnnotation allows one to set arbitrary annotations on a flag in the FlagSet.
 // This is sometimes used by spf13/cobra programs which want to generate additional
 // bash completion information.
 func (f *FlagSet) SetAnnotation(name, key string, values []string) error {
-	normalName := f.normalizeFlagName(name)
-	flag, ok := f.formal[normalName]
-	if !ok {
+	flag := f.lookup(f.normalizeFlagName(name))
+	if flag == nil {
 		return fmt.Errorf("no such flag -%v", name)
 	}
 	if flag.Annotations == nil {
@@ -473,7 +757,8 @@ func (f *FlagSet) SetAnnotation(name, key string, values []string) error {
 // Changed returns true if the flag was explicitly set during Parse() and false
 // otherwise
 func (f *FlagSet) Changed(name string) bool {
-	flag := f.Lookup(name)
+	defer f.rlock()()
+	flag := f.lookup(f.normalizeFlagName(name))
 	// If a flag doesn't exist, it wasn't changed....
 	if flag == nil {
 		return false
@@ -487,16 +772,21 @@ func Set(name, value string) error {
 }
 
 // PrintDefaults prints, to standard error unless configured
-// otherwise, the default values of all defined flags in the set.
+// otherwise, the default values of all defined flags in the set,
+// in sections if flags were put in groups with AddGroup.
 func (f *FlagSet) PrintDefaults() {
-	usages := f.FlagUsages()
+	cols := 0
+	if f.autoWidth {
+		cols = TerminalWidth(f.out())
+	}
+	usages := f.flagUsagesGrouped(cols, f.color && useColor(f.out()))
 	fmt.Fprint(f.out(), usages)
 }
 
 // defaultIsZeroValue returns true if the default value for this flag represents
 // a zero value.
 func (f *Flag) defaultIsZeroValue() bool {
-	switch f.Value.(type) {
+	switch unwrapValue(f.Value).(type) {
 	case boolFlag:
 		return f.DefValue == "false"
 	case *durationValue:
@@ -561,16 +851,21 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 	return
 }
 
-// Splits the string `s` on whitespace into an initial substring up to
-// `i` runes in length and the remainder. Will go `slop` over `i` if
-// that encompasses the entire string (which allows the caller to
-// avoid short orphan words on the final line).
+// Splits the string `s` on whitespace, or between East Asian wide
+// characters, into an initial substring up to `i` columns wide and the
+// remainder. Will go `slop` over `i` if that encompasses the entire
+// string (which allows the caller to avoid short orphan words on the
+// final line).
 func wrapN(i, slop int, s string) (string, string) {
-	if i+slop > len(s) {
+	if i+slop > displayWidth(s) {
 		return s, ""
 	}
 
-	w := strings.LastIndexAny(s[:i], " \t")
+	end := widthIndex(s, i)
+	w := strings.LastIndexAny(s[:end], " \t")
+	if b := lastWideBreak(s, end); b > w+1 {
+		return s[:b], s[b:]
+	}
 	if w <= 0 {
 		return s, ""
 	}
@@ -580,28 +875,32 @@ func wrapN(i, slop int, s string) (string, string) {
 
 // Wraps the string `s` to a maximum width `w` with leading indent
 // `i`. The first line is not indented (this is assumed to be done by
-// caller). Pass `w` == 0 to do no wrapping
+// caller). Pass `w` == 0 to do no wrapping. Lines started by explicit
+// newlines in `s` are wrapped on their own, keeping their indentation.
 func wrap(i, w int, s string) string {
+	lines := strings.Split(s, "\n")
 	if w == 0 {
-		return s
+		return indentLines(i, lines)
 	}
 
 	// space between indent i and end of line width w into which
 	// we should wrap the text.
 	wrap := w - i
 
-	var r, l string
+	var r string
 
 	// Not enough space for sensible wrapping. Wrap as a block on
 	// the next line instead.
 	if wrap < 24 {
-		i = 16
-		wrap = w - i
-		r += "\n" + strings.Repeat(" ", i)
+		r = "\n" + strings.Repeat(" ", 16)
+		wrap = w - 16
 	}
 	// If still not enough space then don't even try to wrap.
 	if wrap < 24 {
-		return s
+		return indentLines(i, lines)
+	}
+	if r != "" {
+		i = 16
 	}
 
 	// Try to avoid short orphan words on the final line, by
@@ -610,86 +909,71 @@ func wrap(i, w int, s string) string {
 	slop := 5
 	wrap = wrap - slop
 
-	// Handle first line, which is indented by the caller (or the
-	// special case above)
-	l, s = wrapN(wrap, slop, s)
-	r = r + l
+	var wrapped []string
+	for _, line := range lines {
+		// Indented paragraphs keep their indentation on every line.
+		text := strings.TrimLeft(line, " \t")
+		lead := line[:len(line)-len(text)]
+		for {
+			var t string
+
+			t, text = wrapN(wrap-displayWidth(lead), slop, text)
+			wrapped = append(wrapped, lead+t)
+			if text == "" {
+				break
+			}
+		}
+	}
 
-	// Now wrap the rest
-	for s != "" {
-		var t string
+	return r + indentLines(i, wrapped)
+}
 
-		t, s = wrapN(wrap, slop, s)
-		r = r + "\n" + strings.Repeat(" ", i) + t
+// indentLines joins the lines, indenting all but the first by i spaces.
+func indentLines(i int, lines []string) string {
+	r := lines[0]
+	for _, l := range lines[1:] {
+		r += "\n"
+		if l != "" {
+			r += strings.Repeat(" ", i) + l
+		}
 	}
-
 	return r
-
 }
 
 // FlagUsagesWrapped returns a string containing the usage information
 // for all flags in the FlagSet. Wrapped to `cols` columns (0 for no
 // wrapping)
 func (f *FlagSet) FlagUsagesWrapped(cols int) string {
-	buf := new(bytes.Buffer)
-
-	lines := make([]string, 0, len(f.formal))
-
-	maxlen := 0
+	var flags []*Flag
 	f.VisitAll(func(flag *Flag) {
-		if flag.Deprecated != "" || flag.Hidden {
-			return
-		}
-
-		line := ""
-		if flag.Shorthand != "" && flag.ShorthandDeprecated == "" {
-			line = fmt.Sprintf("  -%s, --%s", flag.Shorthand, flag.Name)
-		} else {
-			line = fmt.Sprintf("      --%s", flag.Name)
-		}
-
-		varname, usage := UnquoteUsage(flag)
-		if flag.NoOptDefVal != "" {
-			switch flag.Value.Type() {
-			case "string":
-				line += fmt.Sprintf("[=\"%s\"]", flag.NoOptDefVal)
-			case "bool":
-				if flag.NoOptDefVal != "true" {
-					line += fmt.Sprintf("[=%s]", flag.NoOptDefVal)
-				}
-			default:
-				line += fmt.Sprintf("[=%s]", flag.NoOptDefVal)
-			}
-		} else if varname != "" {
-			line += " " + varname
-		}
+		flags = append(flags, flag)
+	})
+	return f.flagUsages(flags, cols, false)
+}
 
-		// This special character will be replaced with spacing once the
-		// correct alignment is calculated
-		line += "\x00"
-		if len(line) > maxlen {
-			maxlen = len(line)
+// flagUsages returns the usage information for the given flags, aligned
+// together and wrapped to cols columns, colorized if color is true.
+func (f *FlagSet) flagUsages(flags []*Flag, cols int, color bool) string {
+	data := UsageData{Cols: cols}
+	for _, flag := range flags {
+		if flag.Hidden || f.positionalArg(flag) != nil {
+			continue
 		}
-
-		line += usage
-		if !flag.defaultIsZeroValue() {
-			if flag.Value.Type() == "string" {
-				line += fmt.Sprintf(" (default %q)", flag.DefValue)
-			} else {
-				line += fmt.Sprintf(" (default %s)", flag.DefValue)
-			}
+		if flag.Deprecated != "" {
+			data.Deprecated = append(data.Deprecated, newFlagUsage(flag))
+			continue
 		}
-
-		lines = append(lines, line)
-	})
-
-	for _, line := range lines {
-		sidx := strings.Index(line, "\x00")
-		spacing := strings.Repeat(" ", maxlen-sidx)
-		// maxlen + 2 comes from + 1 for the \x00 and + 1 for the (deliberate) off-by-one in maxlen-sidx
-		fmt.Fprintln(buf, line[:sidx], spacing, wrap(maxlen+2, cols, line[sidx+1:]))
+		data.Flags = append(data.Flags, newFlagUsage(flag))
 	}
 
+	t := f.usageTemplate
+	if t == nil {
+		t = defaultUsageTemplate
+	}
+	buf := new(bytes.Buffer)
+	if err := executeUsageTemplate(t, buf, data, color); err != nil {
+		fmt.Fprintf(buf, "\nerror rendering flag usages: %v\n", err)
+	}
 	return buf.String()
 }
 
@@ -706,8 +990,15 @@ func PrintDefaults() {
 
 // defaultUsage is the default function to print a usage message.
 func defaultUsage(f *FlagSet) {
-	fmt.Fprintf(f.out(), "Usage of %s:\n", f.name)
+	if len(f.positionals) > 0 {
+		fmt.Fprintf(f.out(), "Usage: %s\n\nArguments:\n%s\nFlags:\n", f.Synopsis(), f.PositionalUsages())
+	} else {
+		fmt.Fprintf(f.out(), "Usage of %s:\n", f.name)
+	}
 	f.PrintDefaults()
+	if deprecated := f.DeprecatedFlagUsages(); deprecated != "" {
+		fmt.Fprintf(f.out(), "\nDeprecated flags:\n%s", deprecated)
+	}
 }
 
 // NOTE: Usage is not just defaultUsage(CommandLine)
@@ -724,7 +1015,10 @@ var Usage = func() {
 }
 
 // NFlag returns the number of flags that have been set.
-func (f *FlagSet) NFlag() int { return len(f.actual) }
+func (f *FlagSet) NFlag() int {
+	defer f.rlock()()
+	return len(f.actual)
+}
 
 // NFlag returns the number of command-line flags that have been set.
 func NFlag() int { return len(CommandLine.actual) }
@@ -789,7 +1083,7 @@ func (f *FlagSet) VarP(value Value, name, shorthand, usage string) {
 func (f *FlagSet) AddFlag(flag *Flag) {
 	normalizedFlagName := f.normalizeFlagName(flag.Name)
 
-	_, alreadyThere := f.formal[normalizedFlagName]
+	alreadyThere := f.lookup(normalizedFlagName) != nil
 	if alreadyThere {
 		msg := fmt.Sprintf("%s flag redefined: %s", f.name, flag.Name)
 		fmt.Fprintln(f.out(), msg)
@@ -800,21 +1094,46 @@ func (f *FlagSet) AddFlag(flag *Flag) {
 	}
 
 	flag.Name = string(normalizedFlagName)
+	if _, ok := flag.Value.(*secretValue); flag.Secret && !ok {
+		markSecret(flag)
+	}
 	f.formal[normalizedFlagName] = flag
 	f.orderedFormal = append(f.orderedFormal, flag)
 
+	aliases := flag.Aliases
+	flag.Aliases = nil
+	for _, alias := range aliases {
+		if err := f.AddAlias(flag.Name, alias); err != nil {
+			fmt.Fprintln(f.out(), err)
+			panic(err)
+		}
+	}
+
 	if flag.Shorthand == "" {
 		return
 	}
-	if len(flag.Shorthand) > 1 {
-		msg := fmt.Sprintf("%q shorthand is more than one ASCII character", flag.Shorthand)
-		fmt.Fprintf(f.out(), msg)
-		panic(msg)
+	if utf8.RuneCountInString(flag.Shorthand) > 1 {
+		if !f.multiLetter {
+			msg := fmt.Sprintf("%q shorthand is more than one character", flag.Shorthand)
+			fmt.Fprint(f.out(), msg)
+			panic(msg)
+		}
+		if f.longShorthands == nil {
+			f.longShorthands = make(map[string]*Flag)
+		}
+		used, alreadyThere := f.longShorthands[flag.Shorthand]
+		if alreadyThere {
+			msg := fmt.Sprintf("unable to redefine %q shorthand in %q flagset: it's already used for %q flag", flag.Shorthand, f.name, used.Name)
+			fmt.Fprint(f.out(), msg)
+			panic(msg)
+		}
+		f.longShorthands[flag.Shorthand] = flag
+		return
 	}
 	if f.shorthands == nil {
-		f.shorthands = make(map[byte]*Flag)
+		f.shorthands = make(map[rune]*Flag)
 	}
-	c := flag.Shorthand[0]
+	c, _ := utf8.DecodeRuneInString(flag.Shorthand)
 	used, alreadyThere := f.shorthands[c]
 	if alreadyThere {
 		msg := fmt.Sprintf("unable to redefine %q shorthand in %q flagset: it's already used for %q flag", c, f.name, used.Name)
@@ -824,8 +1143,28 @@ func (f *FlagSet) AddFlag(flag *Flag) {
 	f.shorthands[c] = flag
 }
 
+// AddAlias registers alias as another long name of the named flag. Parsing,
+// Lookup, Set and Changed all accept the alias in place of the flag name.
+func (f *FlagSet) AddAlias(name, alias string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	normalAlias := f.normalizeFlagName(alias)
+	if used := f.lookup(normalAlias); used != nil {
+		return fmt.Errorf("unable to add alias %q to %q flag in %q flagset: it's already used for %q flag", alias, flag.Name, f.name, used.Name)
+	}
+	if f.aliases == nil {
+		f.aliases = make(map[NormalizedName]*Flag)
+	}
+	f.aliases[normalAlias] = flag
+	flag.Aliases = append(flag.Aliases, string(normalAlias))
+	return nil
+}
+
 // AddFlagSet adds one FlagSet to another. If a flag is already present in f
-// the flag from newSet will be ignored.
+// the flag from newSet will be ignored. See AddFlagSetWithPolicy for other
+// ways to handle conflicts.
 func (f *FlagSet) AddFlagSet(newSet *FlagSet) {
 	if newSet == nil {
 		return
@@ -883,12 +1222,25 @@ func (f *FlagSet) parseLongArg(s string, args []string, fn parseFunc) (a []strin
 
 	split := strings.SplitN(name, "=", 2)
 	name = split[0]
-	flag, exists := f.formal[f.normalizeFlagName(name)]
-	if !exists {
+	flag := f.lookup(f.normalizeFlagName(name))
+	if flag != nil && f.positionalArg(flag) != nil {
+		flag = nil
+	}
+	if flag == nil && f.getopt {
+		// '--fl' (abbreviated flag name)
+		if flag, err = f.lookupPrefix(name); err != nil {
+			err = f.failf("%v", err)
+			return
+		}
+	}
+	if flag == nil {
 		if name == "help" { // special case for nice help message.
 			f.usage()
 			return a, ErrHelp
 		}
+		if f.passthrough {
+			return f.passUnknown(s, len(split) == 1, a), nil
+		}
 		err = f.failf("unknown flag: --%s", name)
 		return
 	}
@@ -897,6 +1249,10 @@ func (f *FlagSet) parseLongArg(s string, args []string, fn parseFunc) (a []strin
 	if len(split) == 2 {
 		// '--flag=arg'
 		value = split[1]
+	} else if flag.NoOptDefVal != "" && len(a) > 0 && flag.accepts(a[0]) {
+		// '--flag arg' (arg was optional, flag is greedy)
+		value = a[0]
+		a = a[1:]
 	} else if flag.NoOptDefVal != "" {
 		// '--flag' (arg was optional)
 		value = flag.NoOptDefVal
@@ -910,6 +1266,10 @@ func (f *FlagSet) parseLongArg(s string, args []string, fn parseFunc) (a []strin
 		return
 	}
 
+	if flag.Secret {
+		f.warnSecret(flag)
+	}
+
 	err = fn(flag, value)
 	return
 }
@@ -920,31 +1280,48 @@ func (f *FlagSet) parseSingleShortArg(shorthands string, args []string, fn parse
 	}
 
 	outArgs = args
-	outShorts = shorthands[1:]
-	c := shorthands[0]
+	c, size := utf8.DecodeRuneInString(shorthands)
+	outShorts = shorthands[size:]
 
 	flag, exists := f.shorthands[c]
+	if exists && f.positionalArg(flag) != nil {
+		exists = false
+	}
 	if !exists {
 		if c == 'h' { // special case for nice help message.
 			f.usage()
 			err = ErrHelp
 			return
 		}
+		if f.passthrough {
+			// The rest of the cluster may be the value of the unknown flag.
+			outShorts = ""
+			outArgs = f.passUnknown("-"+shorthands, len(shorthands) == size, args)
+			return
+		}
 		err = f.failf("unknown shorthand flag: %q in -%s", c, shorthands)
 		return
 	}
 
 	var value string
-	if len(shorthands) > 2 && shorthands[1] == '=' {
-		// '-f=arg'
-		value = shorthands[2:]
+	if !f.getopt && len(shorthands) > size+1 && shorthands[size] == '=' {
+		// '-f=arg' (getopt takes "=arg" as the argument instead)
+		value = shorthands[size+1:]
+		outShorts = ""
+	} else if _, ok := flag.Value.(*optionalArgumentValue); ok && len(shorthands) > size {
+		// '-farg' (arg is optional, getopt style)
+		value = shorthands[size:]
 		outShorts = ""
+	} else if flag.NoOptDefVal != "" && len(shorthands) == size && len(args) > 0 && flag.accepts(args[0]) {
+		// '-f arg' (arg was optional, flag is greedy)
+		value = args[0]
+		outArgs = args[1:]
 	} else if flag.NoOptDefVal != "" {
 		// '-f' (arg was optional)
 		value = flag.NoOptDefVal
-	} else if len(shorthands) > 1 {
+	} else if len(shorthands) > size {
 		// '-farg'
-		value = shorthands[1:]
+		value = shorthands[size:]
 		outShorts = ""
 	} else if len(args) > 0 {
 		// '-f arg'
@@ -956,15 +1333,111 @@ func (f *FlagSet) parseSingleShortArg(shorthands string, args []string, fn parse
 		return
 	}
 
+	f.warnShorthand(flag)
+
+	err = fn(flag, value)
+	return
+}
+
+// isSingleDashLong reports whether the single dash argument s names a long
+// flag, as in "-verbose" or "-port=80". A single letter names a long flag only
+// when no shorthand uses it.
+func (f *FlagSet) isSingleDashLong(s string) bool {
+	name := strings.SplitN(s[1:], "=", 2)[0]
+	switch utf8.RuneCountInString(name) {
+	case 0:
+		return false
+	case 1:
+		c, _ := utf8.DecodeRuneInString(name)
+		if f.shorthands[c] != nil {
+			return false
+		}
+	}
+	if f.multiLetter && f.longShorthands[name] != nil {
+		return false
+	}
+	flag := f.lookup(f.normalizeFlagName(name))
+	return flag != nil && f.positionalArg(flag) == nil
+}
+
+// warnShorthand issues the warnings due when a flag is given by its shorthand.
+func (f *FlagSet) warnShorthand(flag *Flag) {
 	if flag.ShorthandDeprecated != "" {
-		fmt.Fprintf(f.out(), "Flag shorthand -%s has been deprecated, %s\n", flag.Shorthand, flag.ShorthandDeprecated)
+		f.warn(Warning{
+			Kind:    WarnShorthandDeprecated,
+			Flag:    flag,
+			Message: fmt.Sprintf("Flag shorthand -%s has been deprecated, %s", flag.Shorthand, flag.ShorthandDeprecated),
+		})
+	}
+	if flag.Secret {
+		f.warnSecret(flag)
+	}
+}
+
+// parseMultiLetterShortArg parses a single dash argument when multi-letter
+// shorthands are enabled: it names exactly one shorthand.
+func (f *FlagSet) parseMultiLetterShortArg(s string, args []string, fn parseFunc) (a []string, err error) {
+	a = args
+	split := strings.SplitN(s[1:], "=", 2)
+	name := split[0]
+
+	var flag *Flag
+	if utf8.RuneCountInString(name) > 1 {
+		flag = f.longShorthands[name]
+	} else {
+		c, _ := utf8.DecodeRuneInString(name)
+		flag = f.shorthands[c]
+	}
+	if flag != nil && f.positionalArg(flag) != nil {
+		flag = nil
+	}
+	if flag == nil {
+		if name == "h" { // special case for nice help message.
+			f.usage()
+			return a, ErrHelp
+		}
+		if f.passthrough {
+			return f.passUnknown(s, len(split) == 1, a), nil
+		}
+		err = f.failf("unknown shorthand flag: %q in %s", name, s)
+		return
+	}
+
+	var value string
+	if len(split) == 2 {
+		// '-name=arg'
+		value = split[1]
+	} else if flag.NoOptDefVal != "" && len(a) > 0 && flag.accepts(a[0]) {
+		// '-name arg' (arg was optional, flag is greedy)
+		value = a[0]
+		a = a[1:]
+	} else if flag.NoOptDefVal != "" {
+		// '-name' (arg was optional)
+		value = flag.NoOptDefVal
+	} else if len(a) > 0 {
+		// '-name arg'
+		value = a[0]
+		a = a[1:]
+	} else {
+		// '-name' (arg was required)
+		err = f.failf("flag needs an argument: %s", s)
+		return
 	}
 
+	f.warnShorthand(flag)
+
 	err = fn(flag, value)
 	return
 }
 
 func (f *FlagSet) parseShortArg(s string, args []string, fn parseFunc) (a []string, err error) {
+	if f.singleDashLong && f.isSingleDashLong(s) {
+		return f.parseLongArg("-"+s, args, fn)
+	}
+	if f.multiLetter {
+		return f.parseMultiLetterShortArg(s, args, fn)
+	}
+
 	a = args
 	shorthands := s[1:]
 
@@ -980,16 +1453,29 @@ func (f *FlagSet) parseShortArg(s string, args []string, fn parseFunc) (a []stri
 }
 
 func (f *FlagSet) parseArgs(args []string, fn parseFunc) (err error) {
+	defer func() { f.parseSource = nil }()
+	total := len(args)
 	for len(args) > 0 {
 		s := args[0]
+		i := total - len(args)
+		f.parseSource = &Source{Kind: SourceCommandLine, Arg: i, Raw: s}
 		args = args[1:]
 		if len(s) == 0 || s[0] != '-' || len(s) == 1 {
 			if !f.interspersed {
 				f.args = append(f.args, s)
 				f.args = append(f.args, args...)
+				f.addArgIndexes(i, len(args)+1)
+				if f.passthrough {
+					f.passthroughArgs = append(f.passthroughArgs, s)
+					f.passthroughArgs = append(f.passthroughArgs, args...)
+				}
 				return nil
 			}
 			f.args = append(f.args, s)
+			f.addArgIndexes(i, 1)
+			if f.passthrough {
+				f.passthroughArgs = append(f.passthroughArgs, s)
+			}
 			continue
 		}
 
@@ -997,6 +1483,11 @@ func (f *FlagSet) parseArgs(args []string, fn parseFunc) (err error) {
 			if len(s) == 2 { // "--" terminates the flags
 				f.argsLenAtDash = len(f.args)
 				f.args = append(f.args, args...)
+				f.addArgIndexes(i+1, len(args))
+				if f.passthrough {
+					f.passthroughArgs = append(f.passthroughArgs, s)
+					f.passthroughArgs = append(f.passthroughArgs, args...)
+				}
 				break
 			}
 			args, err = f.parseLongArg(s, args, fn)
@@ -1010,6 +1501,14 @@ func (f *FlagSet) parseArgs(args []string, fn parseFunc) (err error) {
 	return
 }
 
+// addArgIndexes records the indexes in the parsed arguments of n arguments
+// appended to args, starting at index i.
+func (f *FlagSet) addArgIndexes(i, n int) {
+	for j := 0; j < n; j++ {
+		f.argIndexes = append(f.argIndexes, i+j)
+	}
+}
+
 // Parse parses flag definitions from the argument list, which should not
 // include the command name.  Must be called after all flags in the FlagSet
 // are defined and before flags are accessed by the program.
@@ -1022,12 +1521,21 @@ func (f *FlagSet) Parse(arguments []string) error {
 	}
 
 	f.args = make([]string, 0, len(arguments))
+	f.argIndexes = nil
+	f.unknownFlags = nil
+	f.passthroughArgs = nil
 
 	set := func(flag *Flag, value string) error {
 		return f.Set(flag.Name, value)
 	}
 
 	err := f.parseArgs(arguments, set)
+	if err == nil {
+		err = f.setPositionals(set)
+	}
+	if err == nil {
+		err = f.validateSet()
+	}
 	if err != nil {
 		switch f.errorHandling {
 		case ContinueOnError:
@@ -1041,6 +1549,17 @@ func (f *FlagSet) Parse(arguments []string) error {
 	return nil
 }
 
+// validateSet runs the function set by SetValidateFunc, if any.
+func (f *FlagSet) validateSet() error {
+	if f.validateFunc == nil {
+		return nil
+	}
+	if err := f.validateFunc(f); err != nil {
+		return f.failf("%v", err)
+	}
+	return nil
+}
+
 type parseFunc func(flag *Flag, value string) error
 
 // ParseAll parses flag definitions from the argument list, which should not
@@ -1051,8 +1570,17 @@ type parseFunc func(flag *Flag, value string) error
 func (f *FlagSet) ParseAll(arguments []string, fn func(flag *Flag, value string) error) error {
 	f.parsed = true
 	f.args = make([]string, 0, len(arguments))
+	f.argIndexes = nil
+	f.unknownFlags = nil
+	f.passthroughArgs = nil
 
 	err := f.parseArgs(arguments, fn)
+	if err == nil {
+		err = f.setPositionals(fn)
+	}
+	if err == nil {
+		err = f.validateSet()
+	}
 	if err != nil {
 		switch f.errorHandling {
 		case ContinueOnError:
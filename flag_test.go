@@ -1083,3 +1083,412 @@ func TestVisitFlagOrder(t *testing.T) {
 		i++
 	})
 }
+
+func TestValidator(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	replicas := f.Int("replicas", 1, "number of replicas")
+	names := f.StringSlice("names", []string{}, "names")
+	err := f.SetValidator("replicas", func(v Value) error {
+		n, _ := strconv.Atoi(v.String())
+		if n%2 == 0 {
+			return fmt.Errorf("must be odd")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("unexpected error from SetValidator:", err)
+	}
+	f.SetValidator("names", func(v Value) error {
+		if len(*names) > 2 {
+			return fmt.Errorf("at most 2 names allowed")
+		}
+		return nil
+	})
+	if err := f.SetValidator("missing", nil); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+
+	err = f.Parse([]string{"--replicas=4"})
+	expected := `invalid argument "4" for "--replicas" flag: must be odd`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+	if *replicas != 1 || f.Changed("replicas") {
+		t.Errorf("expected rejected value to be restored, got %d", *replicas)
+	}
+
+	if err := f.Parse([]string{"--replicas=3", "--names=a,b"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *replicas != 3 {
+		t.Errorf("expected replicas 3, got %d", *replicas)
+	}
+	if err := f.Set("names", "c"); err == nil {
+		t.Error("expected an error for a third name")
+	}
+	if !reflect.DeepEqual(*names, []string{"a", "b"}) {
+		t.Errorf("expected names to be restored, got %v", *names)
+	}
+	if err := f.Parse([]string{"--names=d"}); err == nil {
+		t.Error("expected an error for a third name")
+	}
+}
+
+// listValue is a custom Value accumulating its arguments, which rejects
+// empty ones.
+type listValue []string
+
+func (l *listValue) Set(s string) error {
+	if s == "" {
+		return fmt.Errorf("empty item")
+	}
+	*l = append(*l, s)
+	return nil
+}
+
+func (l *listValue) Type() string { return "list" }
+
+func (l *listValue) String() string { return strings.Join(*l, ",") }
+
+func TestCustomValueLeftAfterError(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	items := new(listValue)
+	f.Var(items, "item", "items")
+
+	if err := f.Parse([]string{"--item=a", "--item=b", "--item="}); err == nil {
+		t.Error("expected an error for an empty item")
+	}
+	if expected := (listValue{"a", "b"}); !reflect.DeepEqual(*items, expected) {
+		t.Errorf("expected %v, got %v", expected, *items)
+	}
+}
+
+func TestValidateFunc(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	var out bytes.Buffer
+	f.SetOutput(&out)
+	f.Bool("json", false, "output JSON")
+	f.Bool("yaml", false, "output YAML")
+	f.SetValidateFunc(func(f *FlagSet) error {
+		if f.Changed("json") && f.Changed("yaml") {
+			return fmt.Errorf("--json and --yaml are mutually exclusive")
+		}
+		return nil
+	})
+
+	if err := f.Parse([]string{"--json"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	err := f.Parse([]string{"--yaml"})
+	if err == nil || err.Error() != "--json and --yaml are mutually exclusive" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if !strings.Contains(out.String(), "mutually exclusive") {
+		t.Errorf("expected error in output, got %q", out.String())
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetValidateFunc(func(f *FlagSet) error { return fmt.Errorf("failed") })
+	if err := f.ParseAll([]string{}, func(*Flag, string) error { return nil }); err == nil {
+		t.Error("expected ParseAll to run the validate func")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	dryRun := f.Bool("dry-run", false, "only print what would be done")
+	if err := f.AddAlias("dry-run", "dryrun"); err != nil {
+		t.Fatal("unexpected error from AddAlias:", err)
+	}
+	f.AddFlag(&Flag{
+		Name:    "level",
+		Value:   newIntValue(0, new(int)),
+		Usage:   "level",
+		Aliases: []string{"lvl"},
+	})
+
+	if err := f.Parse([]string{"--dryrun", "--lvl=3"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*dryRun {
+		t.Error("expected --dryrun to set --dry-run")
+	}
+	if !f.Changed("dry-run") || !f.Changed("dryrun") {
+		t.Error("expected flag to be changed under both names")
+	}
+	if f.Lookup("dryrun") != f.Lookup("dry-run") {
+		t.Error("expected alias to resolve to the flag")
+	}
+	if level, _ := f.GetInt("lvl"); level != 3 {
+		t.Errorf("expected level 3, got %d", level)
+	}
+	if err := f.Set("lvl", "4"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if level, _ := f.GetInt("level"); level != 4 {
+		t.Errorf("expected level 4, got %d", level)
+	}
+	f.Visit(func(flag *Flag) {
+		if flag.Name != "dry-run" && flag.Name != "level" {
+			t.Errorf("expected Visit to report flag names, got %q", flag.Name)
+		}
+	})
+
+	usage := f.FlagUsages()
+	if !strings.Contains(usage, "--dry-run, --dryrun") {
+		t.Errorf("expected aliases in usage, got:\n%s", usage)
+	}
+}
+
+func TestAliasConflicts(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.Bool("dry-run", false, "only print what would be done")
+	f.Bool("force", false, "force")
+	f.AddAlias("dry-run", "simulate")
+
+	if err := f.AddAlias("dry-run", "force"); err == nil {
+		t.Error("expected an error for an alias shadowing a flag")
+	}
+	if err := f.AddAlias("force", "simulate"); err == nil {
+		t.Error("expected an error for an alias used twice")
+	}
+	if err := f.AddAlias("missing", "other"); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected AddFlag to panic for a flag named like an alias")
+		}
+	}()
+	f.Bool("simulate", false, "simulate")
+}
+
+func TestAliasesNormalized(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	dryRun := f.Bool("dry-run", false, "only print what would be done")
+	f.AddAlias("dry-run", "simulate_run")
+	f.SetNormalizeFunc(wordSepNormalizeFunc)
+
+	if err := f.Parse([]string{"--simulate-run"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*dryRun {
+		t.Error("expected normalized alias to set the flag")
+	}
+}
+
+func TestUnicodeShorthand(t *testing.T) {
+	f := NewFlagSet("unicode", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	accent := f.BoolP("accent", "é", false, "accented")
+	umlaut := f.StringP("umlaut", "ä", "", "umlaut")
+	verbose := f.BoolP("verbose", "v", false, "verbose")
+
+	if err := f.Parse([]string{"-véäwert"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*accent || !*verbose || *umlaut != "wert" {
+		t.Errorf("unexpected values %v %v %q", *accent, *verbose, *umlaut)
+	}
+	if err := f.Parse([]string{"-ä=über"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *umlaut != "über" {
+		t.Errorf("expected umlaut über, got %q", *umlaut)
+	}
+	if flag := f.ShorthandLookup("é"); flag == nil || flag.Name != "accent" {
+		t.Errorf("expected ShorthandLookup to find accent, got %v", flag)
+	}
+	err := f.Parse([]string{"-ö"})
+	if err == nil || err.Error() != `unknown shorthand flag: 'ö' in -ö` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if usage := f.FlagUsages(); !strings.Contains(usage, "-é, --accent") {
+		t.Errorf("expected unicode shorthand in usage, got:\n%s", usage)
+	}
+}
+
+func TestMultiLetterShorthands(t *testing.T) {
+	f := NewFlagSet("find", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetMultiLetterShorthands(true)
+	name := f.StringP("name", "name", "", "base name pattern")
+	print0 := f.BoolP("print0", "print0", false, "separate results with NUL")
+	verbose := f.BoolP("verbose", "v", false, "verbose")
+
+	if err := f.Parse([]string{".", "-name", "*.go", "-print0", "-v"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *name != "*.go" || !*print0 || !*verbose {
+		t.Errorf("unexpected values %q %v %v", *name, *print0, *verbose)
+	}
+	if err := f.Parse([]string{"-name=*.c"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *name != "*.c" {
+		t.Errorf("expected name *.c, got %q", *name)
+	}
+	if err := f.Parse([]string{"-vv"}); err == nil {
+		t.Error("expected shorthands not to be clustered")
+	}
+	if flag := f.ShorthandLookup("print0"); flag == nil || flag.Name != "print0" {
+		t.Errorf("expected ShorthandLookup to find print0, got %v", flag)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected multi-letter shorthand to panic without the mode")
+		}
+	}()
+	g := NewFlagSet("test", ContinueOnError)
+	g.SetOutput(ioutil.Discard)
+	g.BoolP("print0", "print0", false, "separate results with NUL")
+}
+
+func TestSingleDashLongFlags(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetSingleDashLongFlags(true)
+	verbose := f.BoolP("verbose", "v", false, "verbose")
+	port := f.IntP("port", "p", 0, "port")
+	all := f.BoolP("all", "a", false, "all")
+	f.Bool("av", false, "a flag named like a shorthand cluster")
+
+	if err := f.Parse([]string{"-verbose", "-port=80"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*verbose || *port != 80 {
+		t.Errorf("unexpected values %v %d", *verbose, *port)
+	}
+	if err := f.Parse([]string{"-port", "81", "-verbose=false"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *verbose || *port != 81 {
+		t.Errorf("unexpected values %v %d", *verbose, *port)
+	}
+	if err := f.Parse([]string{"-vap82"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*verbose || !*all || *port != 82 {
+		t.Errorf("expected shorthands to still work, got %v %v %d", *verbose, *all, *port)
+	}
+	*all = false
+	if err := f.Parse([]string{"-av"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *all || !f.Changed("av") {
+		t.Error("expected the long flag to win over the shorthand cluster")
+	}
+	if err := f.Parse([]string{"-bogus"}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+
+	h := NewFlagSet("test", ContinueOnError)
+	h.SetOutput(ioutil.Discard)
+	h.SetSingleDashLongFlags(true)
+	v := h.Bool("v", false, "a one letter long flag")
+	n := h.Int("n", 0, "a one letter long flag")
+	if err := h.Parse([]string{"-v", "-n=3"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !*v || *n != 3 {
+		t.Errorf("expected one letter long flags to be set, got %v %d", *v, *n)
+	}
+
+	g := NewFlagSet("test", ContinueOnError)
+	g.SetOutput(ioutil.Discard)
+	g.Bool("verbose", false, "verbose")
+	if err := g.Parse([]string{"-verbose"}); err == nil {
+		t.Error("expected single dash long flags to be disabled by default")
+	}
+}
+
+func TestGreedy(t *testing.T) {
+	testCases := []struct {
+		input []string
+		color string
+		level int
+		args  []string
+	}{
+		{[]string{"--color"}, "auto", 0, []string{}},
+		{[]string{"--color", "always"}, "always", 0, []string{}},
+		{[]string{"--color=never"}, "never", 0, []string{}},
+		{[]string{"--color", "file"}, "auto", 0, []string{"file"}},
+		{[]string{"--color", "-v"}, "auto", 0, []string{}},
+		{[]string{"-c", "always"}, "always", 0, []string{}},
+		{[]string{"-vc", "never", "file"}, "never", 0, []string{"file"}},
+		{[]string{"--level", "3", "file"}, "", 3, []string{"file"}},
+		{[]string{"--level", "300"}, "", 1, []string{"300"}},
+		{[]string{"--level", "x"}, "", 1, []string{"x"}},
+		{[]string{"--plain", "x"}, "", 0, []string{"x"}},
+	}
+
+	for _, tc := range testCases {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(ioutil.Discard)
+		f.BoolP("verbose", "v", false, "verbose")
+		color := f.StringP("color", "c", "", "when to color")
+		f.Lookup("color").NoOptDefVal = "auto"
+		f.SetValidator("color", func(v Value) error {
+			switch v.String() {
+			case "auto", "always", "never":
+				return nil
+			}
+			return fmt.Errorf("must be auto, always or never")
+		})
+		f.MarkGreedy("color")
+		level := f.Int("level", 0, "verbosity level")
+		f.Lookup("level").NoOptDefVal = "1"
+		f.SetRange("level", Range{Max: "9"})
+		f.MarkGreedy("level")
+		f.Bool("plain", false, "plain output")
+		f.Lookup("plain").NoOptDefVal = "true"
+
+		if err := f.Parse(tc.input); err != nil {
+			t.Errorf("expected success for %v, got %q", tc.input, err)
+			continue
+		}
+		if *color != tc.color || *level != tc.level || !reflect.DeepEqual(f.Args(), tc.args) {
+			t.Errorf("for %v expected %q %d %v, got %q %d %v", tc.input, tc.color, tc.level, tc.args, *color, *level, f.Args())
+		}
+	}
+}
+
+func TestGreedySlice(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	ints := f.IntSlice("ints", []int{}, "ints")
+	f.Lookup("ints").NoOptDefVal = "0"
+	if err := f.MarkGreedy("ints"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Parse([]string{"--ints", "1,2", "--ints", "x"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !reflect.DeepEqual(*ints, []int{1, 2, 0}) || !reflect.DeepEqual(f.Args(), []string{"x"}) {
+		t.Errorf("unexpected values %v %v", *ints, f.Args())
+	}
+	if err := f.MarkGreedy("bogus"); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestGreedyCustomValue(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	items := new(listValue)
+	f.Var(items, "item", "items")
+	f.Lookup("item").NoOptDefVal = "x"
+	f.SetValidator("item", func(Value) error { return nil })
+	f.MarkGreedy("item")
+
+	if err := f.Parse([]string{"--item=a", "--item", "b"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !reflect.DeepEqual(*items, listValue{"a", "x"}) || !reflect.DeepEqual(f.Args(), []string{"b"}) {
+		t.Errorf("expected [a x] [b], got %v %v", *items, f.Args())
+	}
+}
@@ -0,0 +1,224 @@
+package pflag
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ArgumentKind tells whether an option declared for getopt takes an argument,
+// like the has_arg field of struct option in getopt_long(3).
+type ArgumentKind int
+
+const (
+	// NoArgument options take no argument. They are bool flags which reject
+	// "--name=value".
+	NoArgument ArgumentKind = iota
+	// RequiredArgument options take an argument, attached ("-ovalue",
+	// "--name=value") or as the next argument. They are string flags.
+	RequiredArgument
+	// OptionalArgument options take an argument only when it is attached
+	// ("-ovalue", "--name=value"). They are string flags whose NoOptDefVal is
+	// stored when the argument is omitted.
+	OptionalArgument
+)
+
+// LongOption declares a long option for NewGetoptFlagSet, like an entry of
+// the longopts table of getopt_long(3).
+type LongOption struct {
+	// Name is the long name of the option. It may be empty to only set the
+	// Usage or NoOptDefVal of the short option Val.
+	Name string
+	// HasArg tells whether the option takes an argument.
+	HasArg ArgumentKind
+	// Val, if not zero, is the short option in the optstring which the long
+	// option is a synonym of.
+	Val rune
+	// NoOptDefVal is the value of an OptionalArgument option given without
+	// argument. It defaults to "true".
+	NoOptDefVal string
+	// Usage is the help message of the option.
+	Usage string
+}
+
+// -- noArgument Value
+type noArgumentValue bool
+
+func (b *noArgumentValue) Set(s string) error {
+	if s != "true" {
+		return fmt.Errorf("option doesn't allow an argument")
+	}
+	*b = true
+	return nil
+}
+
+func (b *noArgumentValue) Type() string {
+	return "bool"
+}
+
+func (b *noArgumentValue) String() string { return strconv.FormatBool(bool(*b)) }
+
+func (b *noArgumentValue) IsBoolFlag() bool { return true }
+
+func (b *noArgumentValue) save() func() {
+	value := *b
+	return func() { *b = value }
+}
+
+// -- optionalArgument Value
+type optionalArgumentValue string
+
+func (s *optionalArgumentValue) Set(val string) error {
+	*s = optionalArgumentValue(val)
+	return nil
+}
+
+func (s *optionalArgumentValue) Type() string {
+	return "string"
+}
+
+func (s *optionalArgumentValue) String() string { return string(*s) }
+
+// getoptOption is an option parsed from an optstring.
+type getoptOption struct {
+	short  rune
+	hasArg ArgumentKind
+}
+
+// parseOptstring parses a getopt(3) optstring such as "ab:c::". A leading
+// "+" requests POSIX behavior, where parsing stops at the first non-option.
+func parseOptstring(optstring string) (options []getoptOption, posix bool, err error) {
+	switch {
+	case strings.HasPrefix(optstring, "+"):
+		posix = true
+		optstring = optstring[1:]
+	case strings.HasPrefix(optstring, "-"):
+		optstring = optstring[1:]
+	}
+	// A leading ':' only silences getopt's own error messages.
+	optstring = strings.TrimPrefix(optstring, ":")
+
+	seen := make(map[rune]bool)
+	runes := []rune(optstring)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if c == ':' || c == '-' || c == '=' || c == ' ' {
+			return nil, false, fmt.Errorf("invalid option character %q in optstring %q", c, optstring)
+		}
+		if seen[c] {
+			return nil, false, fmt.Errorf("option %q declared twice in optstring %q", c, optstring)
+		}
+		seen[c] = true
+		option := getoptOption{short: c, hasArg: NoArgument}
+		if i+1 < len(runes) && runes[i+1] == ':' {
+			option.hasArg = RequiredArgument
+			i++
+			if i+1 < len(runes) && runes[i+1] == ':' {
+				option.hasArg = OptionalArgument
+				i++
+			}
+		}
+		options = append(options, option)
+	}
+	return options, posix, nil
+}
+
+// NewGetoptFlagSet returns a new flag set declared like a C program using
+// getopt_long(3): optstring lists the short options, where a letter followed
+// by ':' takes a required argument and one followed by "::" an optional
+// argument, and longopts lists the long options. A short option without a
+// long synonym is named after its letter, so "-a" is also "--a".
+//
+// As with glibc, non-options may be interspersed with options, unless
+// optstring starts with '+' or the POSIXLY_CORRECT environment variable is
+// set, in which case parsing stops at the first non-option. Long options may
+// be abbreviated to any unambiguous prefix.
+func NewGetoptFlagSet(name, optstring string, longopts []LongOption, errorHandling ErrorHandling) (*FlagSet, error) {
+	options, posix, err := parseOptstring(optstring)
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewFlagSet(name, errorHandling)
+	f.getopt = true
+	if posix || os.Getenv("POSIXLY_CORRECT") != "" {
+		f.SetInterspersed(false)
+	}
+
+	synonyms := make(map[rune]LongOption)
+	for _, long := range longopts {
+		if long.Val == 0 {
+			continue
+		}
+		if _, ok := synonyms[long.Val]; ok {
+			return nil, fmt.Errorf("option %q has several long options", long.Val)
+		}
+		synonyms[long.Val] = long
+	}
+
+	for _, option := range options {
+		long, ok := synonyms[option.short]
+		if ok && long.HasArg != option.hasArg {
+			return nil, fmt.Errorf("long option %q and option %q disagree on their argument", long.Name, option.short)
+		}
+		delete(synonyms, option.short)
+		name := long.Name
+		if name == "" {
+			name = string(option.short)
+		}
+		f.getoptVar(name, string(option.short), option.hasArg, long.NoOptDefVal, long.Usage)
+	}
+	for val := range synonyms {
+		return nil, fmt.Errorf("long option for %q which is not in optstring %q", val, optstring)
+	}
+	for _, long := range longopts {
+		if long.Val == 0 {
+			if long.Name == "" {
+				return nil, fmt.Errorf("long option without a name")
+			}
+			f.getoptVar(long.Name, "", long.HasArg, long.NoOptDefVal, long.Usage)
+		}
+	}
+	return f, nil
+}
+
+// getoptVar defines a flag for an option of a getopt flag set.
+func (f *FlagSet) getoptVar(name, shorthand string, hasArg ArgumentKind, noOptDefVal, usage string) {
+	switch hasArg {
+	case NoArgument:
+		flag := f.VarPF(new(noArgumentValue), name, shorthand, usage)
+		flag.NoOptDefVal = "true"
+	case RequiredArgument:
+		f.VarP(newStringValue("", new(string)), name, shorthand, usage)
+	case OptionalArgument:
+		flag := f.VarPF(new(optionalArgumentValue), name, shorthand, usage)
+		flag.NoOptDefVal = noOptDefVal
+		if flag.NoOptDefVal == "" {
+			flag.NoOptDefVal = "true"
+		}
+	}
+}
+
+// lookupPrefix returns the flag whose name or alias starts with the given
+// prefix, as getopt_long(3) accepts abbreviated long options.
+func (f *FlagSet) lookupPrefix(prefix string) (*Flag, error) {
+	var found *Flag
+	var candidates []string
+	check := func(name string, flag *Flag) {
+		if strings.HasPrefix(name, prefix) && f.positionalArg(flag) == nil && flag != found {
+			found = flag
+			candidates = append(candidates, "--"+name)
+		}
+	}
+	for _, flag := range f.orderedFormal {
+		check(flag.Name, flag)
+		for _, alias := range flag.Aliases {
+			check(alias, flag)
+		}
+	}
+	if len(candidates) > 1 {
+		return nil, fmt.Errorf("option --%s is ambiguous; possibilities: %s", prefix, strings.Join(candidates, " "))
+	}
+	return found, nil
+}
@@ -0,0 +1,159 @@
+package pflag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setUpGetopt(t *testing.T, optstring string) *FlagSet {
+	f, err := NewGetoptFlagSet("test", optstring, []LongOption{
+		{Name: "alpha", HasArg: NoArgument, Val: 'a'},
+		{Name: "file", HasArg: RequiredArgument, Val: 'b'},
+		{Name: "color", HasArg: OptionalArgument, Val: 'c', NoOptDefVal: "auto"},
+		{Name: "verbose", HasArg: NoArgument},
+		{Name: "version", HasArg: NoArgument},
+	}, ContinueOnError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.SetOutput(ioutil.Discard)
+	return f
+}
+
+// getoptResult lists the flags set by the parse, sorted by name.
+func getoptResult(f *FlagSet) string {
+	var set []string
+	f.Visit(func(flag *Flag) {
+		set = append(set, fmt.Sprintf("%s=%s", flag.Name, flag.Value))
+	})
+	return strings.Join(set, " ")
+}
+
+// The expected results are those of glibc getopt_long with the same
+// optstring and longopts.
+func TestGetopt(t *testing.T) {
+	testCases := []struct {
+		input   []string
+		success bool
+		flags   string
+		args    []string
+	}{
+		{[]string{"-a", "x", "-bfoo", "y"}, true, "alpha=true file=foo", []string{"x", "y"}},
+		{[]string{"-ab", "foo"}, true, "alpha=true file=foo", []string{}},
+		{[]string{"-abfoo"}, true, "alpha=true file=foo", []string{}},
+		{[]string{"-b", "-a"}, true, "file=-a", []string{}},
+		{[]string{"-c"}, true, "color=auto", []string{}},
+		{[]string{"-c5"}, true, "color=5", []string{}},
+		{[]string{"-c", "5"}, true, "color=auto", []string{"5"}},
+		{[]string{"-b=foo"}, true, "file==foo", []string{}},
+		{[]string{"-c=x"}, true, "color==x", []string{}},
+		{[]string{"-b", "=foo"}, true, "file==foo", []string{}},
+		{[]string{"-ac5"}, true, "alpha=true color=5", []string{}},
+		{[]string{"--color"}, true, "color=auto", []string{}},
+		{[]string{"--color=always"}, true, "color=always", []string{}},
+		{[]string{"--color", "always"}, true, "color=auto", []string{"always"}},
+		{[]string{"--file", "x"}, true, "file=x", []string{}},
+		{[]string{"--file=x"}, true, "file=x", []string{}},
+		{[]string{"--fi", "x"}, true, "file=x", []string{}},
+		{[]string{"--verb"}, true, "verbose=true", []string{}},
+		{[]string{"--al", "x"}, true, "alpha=true", []string{"x"}},
+		{[]string{"x", "--", "-a"}, true, "", []string{"x", "-a"}},
+		{[]string{"-", "-a"}, true, "alpha=true", []string{"-"}},
+		{[]string{"--ver"}, false, "", nil},
+		{[]string{"--alpha=yes"}, false, "", nil},
+		{[]string{"-a=x"}, false, "", nil},
+		{[]string{"-b"}, false, "", nil},
+		{[]string{"--file"}, false, "", nil},
+		{[]string{"-z"}, false, "", nil},
+		{[]string{"--zeta"}, false, "", nil},
+	}
+
+	for _, tc := range testCases {
+		f := setUpGetopt(t, "ab:c::")
+		err := f.Parse(tc.input)
+		if err != nil && tc.success {
+			t.Errorf("expected success for %v, got %q", tc.input, err)
+			continue
+		} else if err == nil && !tc.success {
+			t.Errorf("expected failure for %v", tc.input)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if flags := getoptResult(f); flags != tc.flags {
+			t.Errorf("for %v expected flags %q, got %q", tc.input, tc.flags, flags)
+		}
+		if !reflect.DeepEqual(f.Args(), tc.args) {
+			t.Errorf("for %v expected args %v, got %v", tc.input, tc.args, f.Args())
+		}
+	}
+}
+
+func TestGetoptPosix(t *testing.T) {
+	input := []string{"-a", "x", "-b", "y"}
+	expected := []string{"x", "-b", "y"}
+
+	f := setUpGetopt(t, "+ab:c::")
+	if err := f.Parse(input); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Args(), expected) {
+		t.Errorf("with '+' expected args %v, got %v", expected, f.Args())
+	}
+
+	os.Setenv("POSIXLY_CORRECT", "1")
+	defer os.Unsetenv("POSIXLY_CORRECT")
+	f = setUpGetopt(t, "ab:c::")
+	if err := f.Parse(input); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Args(), expected) {
+		t.Errorf("with POSIXLY_CORRECT expected args %v, got %v", expected, f.Args())
+	}
+}
+
+func TestGetoptShortOnly(t *testing.T) {
+	f, err := NewGetoptFlagSet("test", ":xo:", []LongOption{
+		{Val: 'o', HasArg: RequiredArgument, Usage: "output file"},
+	}, ContinueOnError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Parse([]string{"-xo", "out", "--x"}); err != nil {
+		t.Fatal(err)
+	}
+	if x, err := f.GetBool("x"); err != nil || !x {
+		t.Errorf("expected -x to be set, got %v, %v", x, err)
+	}
+	if o, err := f.GetString("o"); err != nil || o != "out" {
+		t.Errorf("expected -o out, got %q, %v", o, err)
+	}
+	if usage := f.Lookup("o").Usage; usage != "output file" {
+		t.Errorf("expected usage %q, got %q", "output file", usage)
+	}
+}
+
+func TestGetoptDeclarationErrors(t *testing.T) {
+	testCases := []struct {
+		optstring string
+		longopts  []LongOption
+	}{
+		{"aa", nil},
+		{"a:::", nil},
+		{"a-", nil},
+		{"a", []LongOption{{Name: "bravo", Val: 'b'}}},
+		{"a:", []LongOption{{Name: "alpha", Val: 'a'}}},
+		{"a", []LongOption{{Name: "alpha", Val: 'a'}, {Name: "all", Val: 'a'}}},
+		{"a", []LongOption{{HasArg: RequiredArgument}}},
+	}
+
+	for _, tc := range testCases {
+		if _, err := NewGetoptFlagSet("test", tc.optstring, tc.longopts, ContinueOnError); err == nil {
+			t.Errorf("expected error for %q %v", tc.optstring, tc.longopts)
+		}
+	}
+}
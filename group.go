@@ -0,0 +1,84 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// otherFlagsTitle is the title of the section listing the flags which are in
+// no group.
+const otherFlagsTitle = "Other flags"
+
+// AddGroup adds the named flags to the group with the given title, such as
+// "Networking". Grouped flags are listed in sections of the usage message,
+// one per group, in the order the groups were first added; flags in no group
+// are listed last, under "Other flags". AddGroup may be called without flag
+// names to set the order of the groups beforehand.
+func (f *FlagSet) AddGroup(title string, names ...string) error {
+	for _, name := range names {
+		if f.Lookup(name) == nil {
+			return fmt.Errorf("flag %q does not exist", name)
+		}
+	}
+	if !containsString(f.groups, title) {
+		f.groups = append(f.groups, title)
+	}
+	for _, name := range names {
+		f.Lookup(name).Group = title
+	}
+	return nil
+}
+
+// FlagUsagesGrouped returns a string containing the usage information for
+// all flags in the FlagSet, in a section for each group of flags, wrapped to
+// cols columns (0 for no wrapping). Flags are aligned within each section.
+// Without groups, it is the same as FlagUsagesWrapped.
+func (f *FlagSet) FlagUsagesGrouped(cols int) string {
+	return f.flagUsagesGrouped(cols, false)
+}
+
+func (f *FlagSet) flagUsagesGrouped(cols int, color bool) string {
+	titles := append([]string(nil), f.groups...)
+	groups := make(map[string][]*Flag)
+	var other []*Flag
+	f.VisitAll(func(flag *Flag) {
+		if flag.Group == "" {
+			other = append(other, flag)
+			return
+		}
+		if _, ok := groups[flag.Group]; !ok && !containsString(titles, flag.Group) {
+			// The flag was put in a group of another FlagSet.
+			titles = append(titles, flag.Group)
+		}
+		groups[flag.Group] = append(groups[flag.Group], flag)
+	})
+	if len(groups) == 0 {
+		return f.flagUsages(other, cols, color)
+	}
+
+	buf := new(bytes.Buffer)
+	section := func(title string, flags []*Flag) {
+		usages := f.flagUsages(flags, cols, color)
+		if usages == "" {
+			return
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "%s:\n%s", title, usages)
+	}
+	for _, title := range titles {
+		section(title, groups[title])
+	}
+	section(otherFlagsTitle, other)
+	return buf.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
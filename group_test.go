@@ -0,0 +1,65 @@
+package pflag
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFlagUsagesGrouped(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SortFlags = false
+	f.StringP("listen", "l", ":80", "address to listen on")
+	f.Int("log-level", 0, "log verbosity")
+	f.Duration("timeout", 0, "request timeout")
+	f.Bool("version", false, "print the version")
+	f.Bool("hidden", false, "hidden")
+	f.MarkHidden("hidden")
+	f.String("log-file", "", "file to log to")
+
+	if err := f.AddGroup("Logging"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.AddGroup("Networking", "listen", "timeout"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.AddGroup("Logging", "log-level", "log-file"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.AddGroup("Debugging", "hidden"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.AddGroup("Logging", "bogus"); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+
+	expected := `Logging:
+      --log-level int     log verbosity
+      --log-file string   file to log to
+
+Networking:
+  -l, --listen string      address to listen on (default ":80")
+      --timeout duration   request timeout
+
+Other flags:
+      --version   print the version
+`
+	if got := f.FlagUsagesGrouped(0); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+
+	buf := new(bytes.Buffer)
+	f.SetOutput(buf)
+	f.PrintDefaults()
+	if buf.String() != expected {
+		t.Errorf("expected PrintDefaults to print groups, got\n%s", buf)
+	}
+}
+
+func TestFlagUsagesGroupedWithoutGroups(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("listen", ":80", "address to listen on")
+	f.Int("log-level", 0, "log verbosity")
+	if got, expected := f.FlagUsagesGrouped(40), f.FlagUsagesWrapped(40); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
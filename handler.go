@@ -0,0 +1,153 @@
+package pflag
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// maxUpdateSize is the largest body of the POST requests of the handler.
+const maxUpdateSize = 1 << 16
+
+// MarkMutable marks a flag as one which may be changed while the program
+// runs, through the handler returned by NewHandler.
+func (f *FlagSet) MarkMutable(name string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	flag.Mutable = true
+	return nil
+}
+
+// flagJSON is the JSON form of a flag served by the handler.
+type flagJSON struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Default    string `json:"default"`
+	Usage      string `json:"usage"`
+	Changed    bool   `json:"changed"`
+	Source     string `json:"source"`
+	Deprecated string `json:"deprecated,omitempty"`
+	Mutable    bool   `json:"mutable,omitempty"`
+}
+
+// update is the body of the POST requests of the handler.
+type update struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type handler struct {
+	flags        *FlagSet
+	allowUpdates bool
+}
+
+// NewHandler returns an HTTP handler exposing the flags of f, like expvar
+// does for variables. A GET request returns the flags as a JSON array; hidden
+// flags are left out and the values of secret flags are masked.
+//
+// If allowUpdates is true, a POST request with a JSON body such as
+// {"name": "v", "value": "4"} sets a flag marked with MarkMutable, and
+// returns the flag. The request must have the Content-Type
+// application/json, which browsers do not send in cross-origin form posts.
+// The value is given to FlagSet.Set, so that validation, observers and
+// Changed apply. Use SetConcurrencySafe for the FlagSet to be read safely
+// while it is updated.
+func NewHandler(f *FlagSet, allowUpdates bool) http.Handler {
+	return &handler{flags: f, allowUpdates: allowUpdates}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		h.writeJSON(w, http.StatusOK, h.list())
+	case "POST":
+		if !h.allowUpdates {
+			http.Error(w, "updates are disabled", http.StatusForbidden)
+			return
+		}
+		h.update(w, r)
+	default:
+		if h.allowUpdates {
+			w.Header().Set("Allow", "GET, HEAD, POST")
+		} else {
+			w.Header().Set("Allow", "GET, HEAD")
+		}
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *handler) update(w http.ResponseWriter, r *http.Request) {
+	if t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || t != "application/json" {
+		http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+	var u update
+	body := http.MaxBytesReader(w, r.Body, maxUpdateSize)
+	if err := json.NewDecoder(body).Decode(&u); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	flag := h.flags.Lookup(u.Name)
+	if flag == nil || flag.Hidden {
+		http.Error(w, fmt.Sprintf("flag %q does not exist", u.Name), http.StatusNotFound)
+		return
+	}
+	if !flag.Mutable {
+		http.Error(w, fmt.Sprintf("flag %q can't be changed at runtime", flag.Name), http.StatusForbidden)
+		return
+	}
+	if err := h.flags.Set(flag.Name, u.Value); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, fj := range h.list() {
+		if fj.Name == flag.Name {
+			h.writeJSON(w, http.StatusOK, fj)
+			return
+		}
+	}
+}
+
+// list returns the visible flags of the FlagSet.
+func (h *handler) list() []flagJSON {
+	f := h.flags
+	defer f.rlock()()
+
+	// The sorted cache is not updated, as the lock may be held for reading.
+	flags := f.orderedFormal
+	if f.SortFlags {
+		flags = sortFlags(f.formal)
+	}
+	list := []flagJSON{}
+	for _, flag := range flags {
+		if flag.Hidden {
+			continue
+		}
+		list = append(list, flagJSON{
+			Name:       flag.Name,
+			Shorthand:  flag.Shorthand,
+			Type:       flag.Value.Type(),
+			Value:      flag.Value.String(),
+			Default:    flag.DefValue,
+			Usage:      flag.Usage,
+			Changed:    flag.Changed,
+			Source:     flag.Source.Kind.String(),
+			Deprecated: flag.Deprecated,
+			Mutable:    flag.Mutable,
+		})
+	}
+	return list
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
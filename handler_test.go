@@ -0,0 +1,144 @@
+package pflag
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setUpHandler() *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetWarningHandler(func(Warning) {})
+	f.IntP("v", "v", 0, "verbosity")
+	f.SetRange("v", Range{Min: "0", Max: "9"})
+	f.MarkMutable("v")
+	f.Int("port", 80, "port")
+	f.String("token", "hunter2", "token")
+	f.MarkSecret("token")
+	f.Bool("internal", false, "internal")
+	f.MarkHidden("internal")
+	f.MarkMutable("internal")
+	return f
+}
+
+func serve(h http.Handler, method, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/debug/flags", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerList(t *testing.T) {
+	f := setUpHandler()
+	f.Parse([]string{"--port=8080"})
+
+	w := serve(NewHandler(f, false), "GET", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Error("expected the secret to be masked")
+	}
+
+	var flags []flagJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &flags); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, fj := range flags {
+		names = append(names, fj.Name)
+	}
+	if strings.Join(names, ",") != "port,token,v" {
+		t.Errorf("unexpected flags %v", names)
+	}
+	port := flags[0]
+	if port.Value != "8080" || port.Default != "80" || !port.Changed || port.Source != "command line" || port.Type != "int" {
+		t.Errorf("unexpected flag %+v", port)
+	}
+	if !flags[2].Mutable || flags[0].Mutable {
+		t.Error("unexpected mutable flags")
+	}
+}
+
+func TestHandlerUpdate(t *testing.T) {
+	f := setUpHandler()
+	var changes []Change
+	f.AddObserver(func(c Change) { changes = append(changes, c) })
+
+	if w := serve(NewHandler(f, false), "POST", `{"name": "v", "value": "4"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected updates to be disabled, got %d", w.Code)
+	}
+
+	h := NewHandler(f, true)
+	testCases := []struct {
+		body string
+		code int
+	}{
+		{`{"name": "v", "value": "4"}`, http.StatusOK},
+		{`{"name": "v", "value": "10"}`, http.StatusBadRequest},
+		{`{"name": "v", "value": "x"}`, http.StatusBadRequest},
+		{`{"name": "port", "value": "1"}`, http.StatusForbidden},
+		{`{"name": "internal", "value": "true"}`, http.StatusNotFound},
+		{`{"name": "bogus", "value": "1"}`, http.StatusNotFound},
+		{`{"name": `, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := serve(h, "POST", tc.body); w.Code != tc.code {
+			t.Errorf("for %s expected status %d, got %d: %s", tc.body, tc.code, w.Code, w.Body)
+		}
+	}
+
+	if v, _ := f.GetInt("v"); v != 4 || !f.Changed("v") {
+		t.Errorf("expected v to be set to 4, got %d", v)
+	}
+	if f.Changed("port") || f.Changed("internal") {
+		t.Error("expected other flags to be unchanged")
+	}
+	if len(changes) != 1 || changes[0].New != "4" {
+		t.Errorf("expected observers to be notified once, got %v", changes)
+	}
+
+	w := serve(h, "DELETE", "")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") == "" {
+		t.Errorf("expected method not allowed, got %d", w.Code)
+	}
+}
+
+func TestHandlerUpdateRequests(t *testing.T) {
+	f := setUpHandler()
+	h := NewHandler(f, true)
+
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		r := httptest.NewRequest("POST", "/debug/flags", strings.NewReader(`{"name": "v", "value": "4"}`))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("for content type %q expected status %d, got %d", contentType, http.StatusUnsupportedMediaType, w.Code)
+		}
+	}
+	if f.Changed("v") {
+		t.Error("expected v to be unchanged")
+	}
+
+	r := httptest.NewRequest("POST", "/debug/flags", strings.NewReader(`{"name": "v", "value": "4"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a charset to be accepted, got %d: %s", w.Code, w.Body)
+	}
+
+	large := `{"name": "v", "value": "` + strings.Repeat("1", maxUpdateSize) + `"}`
+	if w := serve(h, "POST", large); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a large body to be rejected, got %d", w.Code)
+	}
+}
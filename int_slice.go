@@ -39,6 +39,11 @@ func (s *intSliceValue) Set(val string) error {
 	return nil
 }
 
+func (s *intSliceValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 func (s *intSliceValue) Type() string {
 	return "intSlice"
 }
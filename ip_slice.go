@@ -54,6 +54,11 @@ func (s *ipSliceValue) Set(val string) error {
 	return nil
 }
 
+func (s *ipSliceValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 // Type returns a string that uniquely represents this flag's type.
 func (s *ipSliceValue) Type() string {
 	return "ipSlice"
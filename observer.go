@@ -0,0 +1,47 @@
+package pflag
+
+import "fmt"
+
+// A Change is the change of the value of a flag, given to observers.
+type Change struct {
+	Flag *Flag
+	// Old and New are the String forms of the value before and after the
+	// change. They are masked for secret flags.
+	Old, New string
+	// Source is where the new value came from.
+	Source Source
+}
+
+// AddObserver registers a function called after each successful Set of any
+// flag of the FlagSet, whether by Parse, by the program or with SetWithSource.
+// Observers are called in the order they were added, after the observers of
+// the flag itself, and without holding the lock of a concurrency-safe
+// FlagSet. They should be added before the FlagSet is shared.
+func (f *FlagSet) AddObserver(fn func(Change)) {
+	f.observers = append(f.observers, fn)
+}
+
+// AddFlagObserver registers a function called after each successful Set of
+// the named flag. See AddObserver.
+func (f *FlagSet) AddFlagObserver(name string, fn func(Change)) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	if f.flagObservers == nil {
+		f.flagObservers = make(map[NormalizedName][]func(Change))
+	}
+	normalName := NormalizedName(flag.Name)
+	f.flagObservers[normalName] = append(f.flagObservers[normalName], fn)
+	return nil
+}
+
+// notify calls the observers of the change.
+func (f *FlagSet) notify(change Change) {
+	for _, fn := range f.flagObservers[NormalizedName(change.Flag.Name)] {
+		fn(change)
+	}
+	for _, fn := range f.observers {
+		fn(change)
+	}
+}
@@ -0,0 +1,56 @@
+package pflag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestObservers(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetWarningHandler(func(Warning) {})
+	f.IntP("workers", "w", 4, "workers")
+	f.String("old-workers", "", "workers")
+	f.Deprecate("old-workers", Deprecation{ReplacedBy: "workers"})
+	f.String("token", "", "token")
+	f.MarkSecret("token")
+	f.SetRange("workers", Range{Min: "1"})
+
+	var all, workers []string
+	f.AddObserver(func(c Change) {
+		all = append(all, fmt.Sprintf("%s %s->%s %s", c.Flag.Name, c.Old, c.New, c.Source.Kind))
+	})
+	if err := f.AddFlagObserver("w", func(c Change) {
+		workers = append(workers, c.New)
+	}); err == nil {
+		t.Error("expected an error for a shorthand")
+	}
+	if err := f.AddFlagObserver("workers", func(c Change) {
+		workers = append(workers, c.New)
+	}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	if err := f.Parse([]string{"-w", "8", "--token=t"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	f.Set("workers", "0")
+	f.Set("old-workers", "16")
+	f.SetWithSource("workers", "2", Source{Kind: SourceEnv, Location: "WORKERS"})
+
+	expected := []string{
+		"workers 4->8 command line",
+		"token ->****** command line",
+		"old-workers ->16 program",
+		"workers 8->16 program",
+		"workers 16->2 environment",
+	}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("expected changes\n%q\ngot\n%q", expected, all)
+	}
+	if !reflect.DeepEqual(workers, []string{"8", "16", "2"}) {
+		t.Errorf("unexpected flag changes %q", workers)
+	}
+}
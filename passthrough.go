@@ -0,0 +1,45 @@
+package pflag
+
+import "strings"
+
+// SetPassthroughUnknownFlags sets whether unknown flags are collected instead
+// of being reported as errors. This suits programs wrapping another tool:
+// they parse their own flags and forward the rest, as returned by
+// UnknownFlags or PassthroughArgs, untouched.
+//
+// Since the type of an unknown flag is not known, its value is guessed: the
+// argument following "--name" or a single "-n" is taken as its value unless
+// it starts with a dash, and the rest of a shorthand cluster after an unknown
+// shorthand, as in "-nvalue", is kept with it.
+func (f *FlagSet) SetPassthroughUnknownFlags(enabled bool) {
+	f.passthrough = enabled
+}
+
+// UnknownFlags returns the unknown flags, and their guessed values, found by
+// the last Parse when SetPassthroughUnknownFlags is enabled, in their
+// original order.
+func (f *FlagSet) UnknownFlags() []string {
+	return f.unknownFlags
+}
+
+// PassthroughArgs returns the arguments of the last Parse which are not flags
+// of the FlagSet, when SetPassthroughUnknownFlags is enabled: the unknown
+// flags and their values along with the non-flag arguments and the "--"
+// terminator, all in their original order.
+func (f *FlagSet) PassthroughArgs() []string {
+	return f.passthroughArgs
+}
+
+// passUnknown records the unknown flag s and, if it may take a value and the
+// next argument looks like one, that argument too. It returns the remaining
+// arguments.
+func (f *FlagSet) passUnknown(s string, mayTakeValue bool, args []string) []string {
+	f.unknownFlags = append(f.unknownFlags, s)
+	f.passthroughArgs = append(f.passthroughArgs, s)
+	if mayTakeValue && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		f.unknownFlags = append(f.unknownFlags, args[0])
+		f.passthroughArgs = append(f.passthroughArgs, args[0])
+		return args[1:]
+	}
+	return args
+}
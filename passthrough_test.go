@@ -0,0 +1,74 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestPassthroughUnknownFlags(t *testing.T) {
+	testCases := []struct {
+		input       []string
+		unknown     []string
+		passthrough []string
+		args        []string
+	}{
+		{
+			[]string{"--other", "x", "--verbose", "a", "--flag=1", "b"},
+			[]string{"--other", "x", "--flag=1"},
+			[]string{"--other", "x", "a", "--flag=1", "b"},
+			[]string{"a", "b"},
+		},
+		{
+			[]string{"--other", "--verbose", "-o", "-x", "out"},
+			[]string{"--other", "-x", "out"},
+			[]string{"--other", "-x", "out"},
+			[]string{},
+		},
+		{
+			[]string{"-vx", "1", "-yz", "a"},
+			[]string{"-x", "1", "-yz"},
+			[]string{"-x", "1", "-yz", "a"},
+			[]string{"a"},
+		},
+		{
+			[]string{"a", "--other", "--", "--verbose", "b"},
+			[]string{"--other"},
+			[]string{"a", "--other", "--", "--verbose", "b"},
+			[]string{"a", "--verbose", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		f := NewFlagSet("test", ContinueOnError)
+		f.SetOutput(ioutil.Discard)
+		f.SetPassthroughUnknownFlags(true)
+		f.BoolP("verbose", "v", false, "verbose")
+		f.BoolP("other-known", "o", false, "known")
+
+		if err := f.Parse(tc.input); err != nil {
+			t.Errorf("expected success for %v, got %q", tc.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(f.UnknownFlags(), tc.unknown) {
+			t.Errorf("for %v expected unknown flags %v, got %v", tc.input, tc.unknown, f.UnknownFlags())
+		}
+		if !reflect.DeepEqual(f.PassthroughArgs(), tc.passthrough) {
+			t.Errorf("for %v expected passthrough args %v, got %v", tc.input, tc.passthrough, f.PassthroughArgs())
+		}
+		if !reflect.DeepEqual(f.Args(), tc.args) {
+			t.Errorf("for %v expected args %v, got %v", tc.input, tc.args, f.Args())
+		}
+	}
+}
+
+func TestPassthroughDisabled(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	if err := f.Parse([]string{"--other"}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+	if f.UnknownFlags() != nil || f.PassthroughArgs() != nil {
+		t.Error("expected no passthrough arguments")
+	}
+}
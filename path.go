@@ -0,0 +1,196 @@
+package pflag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Annotation keys understood by spf13/cobra shell completion. Path flags set
+// one of them so that completion offers files or directories.
+const (
+	// BashCompFilenameExt marks a flag as taking a file name. The values
+	// restrict completion to the given extensions; empty means any file.
+	BashCompFilenameExt = "cobra_annotation_bash_completion_filename_extensions"
+	// BashCompSubdirsInDir marks a flag as taking a directory name.
+	BashCompSubdirsInDir = "cobra_annotation_bash_completion_subdirs_in_dir"
+)
+
+// PathCheck is a set of checks applied by path flags to their argument.
+// Checks are combined with bitwise OR.
+type PathCheck int
+
+const (
+	// PathMustExist requires the path to exist.
+	PathMustExist PathCheck = 1 << iota
+	// PathMustNotExist requires the path not to exist.
+	PathMustNotExist
+	// PathMustBeDir requires the path to be an existing directory.
+	PathMustBeDir
+	// PathMustBeFile requires the path to be an existing regular file.
+	PathMustBeFile
+	// PathMustBeReadable requires the path to be an existing, readable file
+	// or directory.
+	PathMustBeReadable
+	// PathExpand expands a leading "~" to the user's home directory and
+	// $VAR or ${VAR} references to environment variables before checking.
+	PathExpand
+)
+
+// -- path Value
+type pathValue struct {
+	value  *string
+	checks PathCheck
+}
+
+func newPathValue(val string, p *string, checks PathCheck) *pathValue {
+	*p = val
+	return &pathValue{value: p, checks: checks}
+}
+
+func (p *pathValue) Set(val string) error {
+	path, err := checkPath(val, p.checks)
+	if err != nil {
+		return err
+	}
+	*p.value = path
+	return nil
+}
+
+func (p *pathValue) Type() string {
+	return "path"
+}
+
+func (p *pathValue) String() string { return *p.value }
+
+func (p *pathValue) save() func() {
+	value := *p.value
+	return func() { *p.value = value }
+}
+
+// expandPath replaces a leading "~" with the home directory of the current
+// user and expands environment variables.
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = home + path[1:]
+	}
+	return os.ExpandEnv(path), nil
+}
+
+// checkPath expands path if requested and verifies it against checks. It
+// returns the path as it should be stored.
+func checkPath(path string, checks PathCheck) (string, error) {
+	if checks&PathExpand != 0 {
+		var err error
+		if path, err = expandPath(path); err != nil {
+			return "", err
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	exists := err == nil
+
+	if checks&PathMustNotExist != 0 && exists {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	if checks&(PathMustExist|PathMustBeDir|PathMustBeFile|PathMustBeReadable) != 0 && !exists {
+		return "", fmt.Errorf("%s does not exist", path)
+	}
+	if checks&PathMustBeDir != 0 && !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	if checks&PathMustBeFile != 0 && !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	if checks&PathMustBeReadable != 0 {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("%s is not readable", path)
+		}
+		file.Close()
+	}
+	return path, nil
+}
+
+func pathConv(sval string) (interface{}, error) {
+	return sval, nil
+}
+
+// GetPath return the path value of a flag with the given name
+func (f *FlagSet) GetPath(name string) (string, error) {
+	val, err := f.getFlagType(name, "path", pathConv)
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+// pathVarPF defines a path flag and annotates it for shell completion.
+func (f *FlagSet) pathVarPF(p *string, name, shorthand string, value string, checks PathCheck, usage string) *Flag {
+	flag := f.VarPF(newPathValue(value, p, checks), name, shorthand, usage)
+	if checks&PathMustBeDir != 0 {
+		flag.Annotations = map[string][]string{BashCompSubdirsInDir: {}}
+	} else {
+		flag.Annotations = map[string][]string{BashCompFilenameExt: {}}
+	}
+	return flag
+}
+
+// PathVar defines a file system path flag with specified name, default value,
+// checks and usage string. The argument p points to a string variable in which
+// to store the value of the flag. The default value is not checked.
+func (f *FlagSet) PathVar(p *string, name string, value string, checks PathCheck, usage string) {
+	f.pathVarPF(p, name, "", value, checks, usage)
+}
+
+// PathVarP is like PathVar, but accepts a shorthand letter that can be used after a single dash.
+func (f *FlagSet) PathVarP(p *string, name, shorthand string, value string, checks PathCheck, usage string) {
+	f.pathVarPF(p, name, shorthand, value, checks, usage)
+}
+
+// PathVar defines a file system path flag with specified name, default value,
+// checks and usage string. The argument p points to a string variable in which
+// to store the value of the flag. The default value is not checked.
+func PathVar(p *string, name string, value string, checks PathCheck, usage string) {
+	CommandLine.pathVarPF(p, name, "", value, checks, usage)
+}
+
+// PathVarP is like PathVar, but accepts a shorthand letter that can be used after a single dash.
+func PathVarP(p *string, name, shorthand string, value string, checks PathCheck, usage string) {
+	CommandLine.pathVarPF(p, name, shorthand, value, checks, usage)
+}
+
+// Path defines a file system path flag with specified name, default value,
+// checks and usage string. The return value is the address of a string
+// variable that stores the value of the flag.
+func (f *FlagSet) Path(name string, value string, checks PathCheck, usage string) *string {
+	p := new(string)
+	f.PathVarP(p, name, "", value, checks, usage)
+	return p
+}
+
+// PathP is like Path, but accepts a shorthand letter that can be used after a single dash.
+func (f *FlagSet) PathP(name, shorthand string, value string, checks PathCheck, usage string) *string {
+	p := new(string)
+	f.PathVarP(p, name, shorthand, value, checks, usage)
+	return p
+}
+
+// Path defines a file system path flag with specified name, default value,
+// checks and usage string. The return value is the address of a string
+// variable that stores the value of the flag.
+func Path(name string, value string, checks PathCheck, usage string) *string {
+	return CommandLine.PathP(name, "", value, checks, usage)
+}
+
+// PathP is like Path, but accepts a shorthand letter that can be used after a single dash.
+func PathP(name, shorthand string, value string, checks PathCheck, usage string) *string {
+	return CommandLine.PathP(name, shorthand, value, checks, usage)
+}
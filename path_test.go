@@ -0,0 +1,115 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setUpPath(p *string, checks PathCheck) *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.PathVar(p, "path", "", checks, "a path")
+	return f
+}
+
+func TestPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pflag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	testCases := []struct {
+		input   string
+		checks  PathCheck
+		success bool
+	}{
+		{missing, 0, true},
+		{missing, PathMustExist, false},
+		{file, PathMustExist, true},
+		{dir, PathMustExist, true},
+		{missing, PathMustNotExist, true},
+		{file, PathMustNotExist, false},
+		{dir, PathMustBeDir, true},
+		{file, PathMustBeDir, false},
+		{missing, PathMustBeDir, false},
+		{file, PathMustBeFile, true},
+		{dir, PathMustBeFile, false},
+		{file, PathMustBeReadable, true},
+		{missing, PathMustBeReadable, false},
+	}
+
+	for i := range testCases {
+		var path string
+		tc := &testCases[i]
+		f := setUpPath(&path, tc.checks)
+
+		err := f.Parse([]string{"--path=" + tc.input})
+		if err != nil && tc.success {
+			t.Errorf("expected success for %q, got %q", tc.input, err)
+			continue
+		} else if err == nil && !tc.success {
+			t.Errorf("expected failure for %q", tc.input)
+			continue
+		} else if err != nil {
+			if !strings.HasPrefix(err.Error(), "invalid argument") {
+				t.Errorf("expected invalid argument error, got %q", err)
+			}
+			continue
+		}
+		getPath, err := f.GetPath("path")
+		if err != nil {
+			t.Errorf("got error trying to fetch the path flag: %v", err)
+		}
+		if path != tc.input || getPath != tc.input {
+			t.Errorf("expected %q, got %q and %q", tc.input, path, getPath)
+		}
+	}
+}
+
+func TestPathExpand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory:", err)
+	}
+	os.Setenv("PFLAG_TEST_PATH", "sub")
+	defer os.Unsetenv("PFLAG_TEST_PATH")
+
+	var path string
+	f := setUpPath(&path, PathExpand)
+	if err := f.Parse([]string{"--path", "~/$PFLAG_TEST_PATH/file"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if expected := home + "/sub/file"; path != expected {
+		t.Errorf("expected %q, got %q", expected, path)
+	}
+}
+
+func TestPathAnnotations(t *testing.T) {
+	var file, dir string
+	f := NewFlagSet("test", ContinueOnError)
+	f.PathVar(&file, "file", "", PathMustBeFile, "a file")
+	f.PathVar(&dir, "dir", "", PathMustBeDir, "a directory")
+
+	// The keys must be those of spf13/cobra.
+	if BashCompFilenameExt != "cobra_annotation_bash_completion_filename_extensions" {
+		t.Errorf("unexpected key %q for file names", BashCompFilenameExt)
+	}
+	if BashCompSubdirsInDir != "cobra_annotation_bash_completion_subdirs_in_dir" {
+		t.Errorf("unexpected key %q for directories", BashCompSubdirsInDir)
+	}
+	if _, ok := f.Lookup("file").Annotations[BashCompFilenameExt]; !ok {
+		t.Errorf("expected file flag to be annotated with %s", BashCompFilenameExt)
+	}
+	if _, ok := f.Lookup("dir").Annotations[BashCompSubdirsInDir]; !ok {
+		t.Errorf("expected dir flag to be annotated with %s", BashCompSubdirsInDir)
+	}
+}
@@ -0,0 +1,178 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Arity tells how many arguments a positional argument takes.
+type Arity int
+
+const (
+	// ArgRequired takes exactly one argument.
+	ArgRequired Arity = iota
+	// ArgOptional takes zero or one argument.
+	ArgOptional
+	// ArgVariadic takes one or more arguments.
+	ArgVariadic
+	// ArgOptionalVariadic takes zero or more arguments.
+	ArgOptionalVariadic
+)
+
+// positional is a flag declared as a positional argument.
+type positional struct {
+	flag  *Flag
+	arity Arity
+}
+
+// name returns the name of the argument as shown in usage messages.
+func (p *positional) name() string {
+	return strings.ToUpper(p.flag.Name)
+}
+
+// synopsis returns the argument as shown in the usage synopsis, e.g. "SRC...".
+func (p *positional) synopsis() string {
+	switch p.arity {
+	case ArgOptional:
+		return "[" + p.name() + "]"
+	case ArgVariadic:
+		return p.name() + "..."
+	case ArgOptionalVariadic:
+		return "[" + p.name() + "...]"
+	}
+	return p.name()
+}
+
+// min returns the number of arguments the positional argument requires.
+func (p *positional) min() int {
+	if p.arity == ArgRequired || p.arity == ArgVariadic {
+		return 1
+	}
+	return 0
+}
+
+// MarkPositional turns the named flag into a positional argument. The flag is
+// no longer accepted as an option nor listed with the other flags; instead
+// the arguments left once the flags are parsed are set, in the order the
+// positional arguments were declared, to the flag with FlagSet.Set. So any
+// flag type can be used, and validators and ranges apply. A variadic argument
+// calls Set once per argument, which suits slice and array flags.
+//
+// The name of the argument in usage messages is the flag name in upper case.
+// At most one positional argument can be variadic, and it may be followed by
+// required arguments only, as in "SRC... DEST".
+func (f *FlagSet) MarkPositional(name string, arity Arity) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	if f.positionalArg(flag) != nil {
+		return fmt.Errorf("flag %q is already a positional argument", name)
+	}
+	for _, p := range f.positionals {
+		if p.arity == ArgVariadic || p.arity == ArgOptionalVariadic {
+			if arity != ArgRequired {
+				return fmt.Errorf("positional argument %q can't follow variadic argument %q", name, p.flag.Name)
+			}
+		}
+		if p.arity == ArgOptional && arity != ArgOptional {
+			return fmt.Errorf("positional argument %q can't follow optional argument %q", name, p.flag.Name)
+		}
+	}
+	f.positionals = append(f.positionals, &positional{flag: flag, arity: arity})
+	return nil
+}
+
+// positionalArg returns the positional argument declared for flag, or nil.
+func (f *FlagSet) positionalArg(flag *Flag) *positional {
+	for _, p := range f.positionals {
+		if p.flag == flag {
+			return p
+		}
+	}
+	return nil
+}
+
+// setPositionals assigns the arguments left after parsing to the positional
+// arguments, calling fn for each value like for the values of flags.
+func (f *FlagSet) setPositionals(fn parseFunc) error {
+	if len(f.positionals) == 0 {
+		return nil
+	}
+
+	// after[i] is the number of arguments required after positional i.
+	after := make([]int, len(f.positionals))
+	for i := len(f.positionals) - 2; i >= 0; i-- {
+		after[i] = after[i+1] + f.positionals[i+1].min()
+	}
+
+	args := f.args
+	indexes := f.argIndexes
+	for i, p := range f.positionals {
+		n := 0
+		switch p.arity {
+		case ArgRequired:
+			n = 1
+		case ArgOptional:
+			if len(args) > after[i] {
+				n = 1
+			}
+		case ArgVariadic:
+			n = len(args) - after[i]
+			if n < 1 {
+				n = 1
+			}
+		case ArgOptionalVariadic:
+			n = len(args) - after[i]
+		}
+		if n > len(args) {
+			return f.failf("missing argument %s", p.name())
+		}
+		for j, arg := range args[:n] {
+			f.parseSource = &Source{Kind: SourceCommandLine, Arg: indexes[j], Raw: arg}
+			err := fn(p.flag, arg)
+			f.parseSource = nil
+			if err != nil {
+				return f.failf("%v", err)
+			}
+		}
+		args = args[n:]
+		indexes = indexes[n:]
+	}
+	if len(args) > 0 {
+		return f.failf("unexpected argument %q", args[0])
+	}
+	return nil
+}
+
+// Synopsis returns the usage synopsis of the FlagSet, e.g.
+// "tool [flags] SRC... DEST".
+func (f *FlagSet) Synopsis() string {
+	synopsis := []string{f.name, "[flags]"}
+	for _, p := range f.positionals {
+		synopsis = append(synopsis, p.synopsis())
+	}
+	return strings.Join(synopsis, " ")
+}
+
+// PositionalUsages returns a string containing the usage information for the
+// positional arguments of the FlagSet.
+func (f *FlagSet) PositionalUsages() string {
+	buf := new(bytes.Buffer)
+
+	maxlen := 0
+	for _, p := range f.positionals {
+		if w := displayWidth(p.name()); w > maxlen {
+			maxlen = w
+		}
+	}
+	for _, p := range f.positionals {
+		_, usage := UnquoteUsage(p.flag)
+		if !p.flag.defaultIsZeroValue() {
+			usage += fmt.Sprintf(" (default %s)", p.flag.DefValue)
+		}
+		fmt.Fprintf(buf, "  %s%s   %s\n", p.name(), strings.Repeat(" ", maxlen-displayWidth(p.name())), usage)
+	}
+	return buf.String()
+}
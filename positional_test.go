@@ -0,0 +1,163 @@
+package pflag
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setUpCopy() (f *FlagSet, src *[]string, dest *string) {
+	f = NewFlagSet("cp", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.Bool("force", false, "overwrite existing files")
+	src = f.StringArray("src", []string{}, "files to copy")
+	dest = f.String("dest", "", "destination")
+	f.MarkPositional("src", ArgVariadic)
+	f.MarkPositional("dest", ArgRequired)
+	return f, src, dest
+}
+
+func TestPositional(t *testing.T) {
+	testCases := []struct {
+		input   []string
+		success bool
+		src     []string
+		dest    string
+	}{
+		{[]string{"a", "b"}, true, []string{"a"}, "b"},
+		{[]string{"a", "--force", "b", "c"}, true, []string{"a", "b"}, "c"},
+		{[]string{"--", "-a", "b"}, true, []string{"-a"}, "b"},
+		{[]string{"a"}, false, nil, ""},
+		{[]string{}, false, nil, ""},
+		{[]string{"--dest=b", "a"}, false, nil, ""},
+	}
+
+	for _, tc := range testCases {
+		f, src, dest := setUpCopy()
+		err := f.Parse(tc.input)
+		if err != nil && tc.success {
+			t.Errorf("expected success for %v, got %q", tc.input, err)
+			continue
+		} else if err == nil && !tc.success {
+			t.Errorf("expected failure for %v", tc.input)
+			continue
+		} else if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(*src, tc.src) || *dest != tc.dest {
+			t.Errorf("for %v expected %v %q, got %v %q", tc.input, tc.src, tc.dest, *src, *dest)
+		}
+		if !f.Changed("dest") {
+			t.Error("expected positional argument to be changed")
+		}
+	}
+}
+
+func TestPositionalParseAll(t *testing.T) {
+	f, src, dest := setUpCopy()
+	var got []string
+	err := f.ParseAll([]string{"--force", "a", "b"}, func(flag *Flag, value string) error {
+		got = append(got, flag.Name+"="+value)
+		return f.Set(flag.Name, value)
+	})
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	expected := []string{"force=true", "src=a", "dest=b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected fn to be called with %v, got %v", expected, got)
+	}
+	if !reflect.DeepEqual(*src, []string{"a"}) || *dest != "b" {
+		t.Errorf("unexpected values %v %q", *src, *dest)
+	}
+}
+
+func TestPositionalOptional(t *testing.T) {
+	f := NewFlagSet("ping", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	host := f.IP("host", nil, "host to ping")
+	interval := f.Duration("interval", time.Second, "interval between pings")
+	f.MarkPositional("host", ArgRequired)
+	f.MarkPositional("interval", ArgOptional)
+
+	if err := f.Parse([]string{"127.0.0.1"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !host.Equal(net.ParseIP("127.0.0.1")) || *interval != time.Second {
+		t.Errorf("unexpected values %v %v", *host, *interval)
+	}
+	if err := f.Parse([]string{"127.0.0.1", "5s"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *interval != 5*time.Second {
+		t.Errorf("expected interval 5s, got %v", *interval)
+	}
+
+	err := f.Parse([]string{"localhost"})
+	expected := `invalid argument "localhost" for HOST: failed to parse IP: "localhost"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+	err = f.Parse([]string{"127.0.0.1", "5s", "extra"})
+	if err == nil || err.Error() != `unexpected argument "extra"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMarkPositionalErrors(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("a", "", "a")
+	f.String("b", "", "b")
+	f.String("c", "", "c")
+
+	if err := f.MarkPositional("missing", ArgRequired); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+	f.MarkPositional("a", ArgOptional)
+	if err := f.MarkPositional("a", ArgOptional); err == nil {
+		t.Error("expected an error for a flag declared twice")
+	}
+	if err := f.MarkPositional("b", ArgRequired); err == nil {
+		t.Error("expected an error for a required argument after an optional one")
+	}
+
+	f = NewFlagSet("test", ContinueOnError)
+	f.String("a", "", "a")
+	f.String("b", "", "b")
+	f.MarkPositional("a", ArgOptionalVariadic)
+	if err := f.MarkPositional("b", ArgOptional); err == nil {
+		t.Error("expected an error for an optional argument after a variadic one")
+	}
+}
+
+func TestPositionalUsage(t *testing.T) {
+	f, _, _ := setUpCopy()
+	out := new(bytes.Buffer)
+	f.SetOutput(out)
+
+	if synopsis := f.Synopsis(); synopsis != "cp [flags] SRC... DEST" {
+		t.Errorf("unexpected synopsis %q", synopsis)
+	}
+
+	f.Parse([]string{"a"})
+	expected := `missing argument DEST
+Usage: cp [flags] SRC... DEST
+
+Arguments:
+  SRC    files to copy
+  DEST   destination
+
+Flags:
+      --force   overwrite existing files
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+	if strings.Contains(f.FlagUsages(), "--dest") {
+		t.Error("expected positional arguments not to be listed as flags")
+	}
+}
@@ -0,0 +1,134 @@
+package pflag
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// Range constrains the value of a numeric or duration flag. Bounds are
+// written as they would be on the command line (e.g. "1", "0.5", "10s"); an
+// empty bound is unbounded. Bounds are inclusive unless the matching
+// Exclusive field is set.
+type Range struct {
+	Min          string
+	Max          string
+	MinExclusive bool
+	MaxExclusive bool
+}
+
+// String describes the range, e.g. "between 1 and 256" or "greater than 0".
+func (r *Range) String() string {
+	var lower, upper string
+	if r.Min != "" {
+		if r.MinExclusive {
+			lower = "greater than " + r.Min
+		} else {
+			lower = "at least " + r.Min
+		}
+	}
+	if r.Max != "" {
+		if r.MaxExclusive {
+			upper = "less than " + r.Max
+		} else {
+			upper = "at most " + r.Max
+		}
+	}
+	switch {
+	case lower == "":
+		return upper
+	case upper == "":
+		return lower
+	case !r.MinExclusive && !r.MaxExclusive:
+		return fmt.Sprintf("between %s and %s", r.Min, r.Max)
+	}
+	return lower + " and " + upper
+}
+
+// check returns an error if the current value of v lies outside the range.
+func (r *Range) check(v Value) error {
+	n, err := rangeNumber(v, v.String())
+	if err != nil {
+		return err
+	}
+	if r.Min != "" {
+		min, err := rangeNumber(v, r.Min)
+		if err != nil {
+			return err
+		}
+		if c := n.Cmp(min); c < 0 || (c == 0 && r.MinExclusive) {
+			return fmt.Errorf("must be %s", r)
+		}
+	}
+	if r.Max != "" {
+		max, err := rangeNumber(v, r.Max)
+		if err != nil {
+			return err
+		}
+		if c := n.Cmp(max); c > 0 || (c == 0 && r.MaxExclusive) {
+			return fmt.Errorf("must be %s", r)
+		}
+	}
+	return nil
+}
+
+// rangeNumber parses s the way the built-in Value v parses its argument and
+// returns it as an exact big.Float, so that int64, uint64, float64 and
+// durations all compare correctly.
+func rangeNumber(v Value, s string) (*big.Float, error) {
+	n := new(big.Float)
+	v = unwrapValue(v)
+	switch v.(type) {
+	case *intValue, *int8Value, *int32Value, *int64Value, *countValue:
+		i, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return n.SetInt64(i), nil
+	case *uintValue, *uint8Value, *uint16Value, *uint32Value, *uint64Value:
+		u, err := strconv.ParseUint(s, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return n.SetUint64(u), nil
+	case *float32Value, *float64Value:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(f) {
+			return nil, fmt.Errorf("%s is not a number", s)
+		}
+		return n.SetFloat64(f), nil
+	case *durationValue:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return n.SetInt64(int64(d)), nil
+	}
+	return nil, fmt.Errorf("range constraints are not supported for %s flags", v.Type())
+}
+
+// SetRange constrains the values accepted by the named flag, which must be
+// one of the built-in integer, float, count or duration flags. Arguments
+// outside the range are rejected by Set and the range is shown in the usage
+// message.
+func (f *FlagSet) SetRange(name string, r Range) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	for _, bound := range []string{r.Min, r.Max} {
+		if bound == "" {
+			continue
+		}
+		if _, err := rangeNumber(flag.Value, bound); err != nil {
+			return fmt.Errorf("invalid range bound %q for flag %q: %v", bound, name, err)
+		}
+	}
+	flag.Range = &r
+	return nil
+}
@@ -0,0 +1,120 @@
+package pflag
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	testCases := []struct {
+		r       Range
+		input   string
+		success bool
+	}{
+		{Range{Min: "1", Max: "256"}, "1", true},
+		{Range{Min: "1", Max: "256"}, "256", true},
+		{Range{Min: "1", Max: "256"}, "0", false},
+		{Range{Min: "1", Max: "256"}, "257", false},
+		{Range{Min: "1", Max: "256", MinExclusive: true}, "1", false},
+		{Range{Min: "1", Max: "256", MaxExclusive: true}, "256", false},
+		{Range{Min: "1"}, "9223372036854775807", true},
+		{Range{Max: "-1"}, "-2", true},
+		{Range{Max: "-1"}, "0x10", false},
+	}
+
+	for i := range testCases {
+		tc := &testCases[i]
+		var workers int
+		f := NewFlagSet("test", ContinueOnError)
+		f.IntVar(&workers, "workers", 4, "number of workers")
+		if err := f.SetRange("workers", tc.r); err != nil {
+			t.Fatal("unexpected error from SetRange:", err)
+		}
+		err := f.Set("workers", tc.input)
+		if err != nil && tc.success {
+			t.Errorf("expected success for %q in %s, got %q", tc.input, &tc.r, err)
+		} else if err == nil && !tc.success {
+			t.Errorf("expected failure for %q in %s", tc.input, &tc.r)
+		} else if err != nil {
+			if workers != 4 {
+				t.Errorf("expected rejected value to be restored, got %d", workers)
+			}
+			if f.Changed("workers") {
+				t.Errorf("expected rejected value to leave the flag unchanged")
+			}
+		}
+	}
+}
+
+func TestRangeFloatAndDuration(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	ratio := f.Float64("ratio", 0.5, "ratio")
+	timeout := f.Duration("timeout", time.Second, "timeout")
+	if err := f.SetRange("ratio", Range{Min: "0", Max: "1", MinExclusive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetRange("timeout", Range{Max: "1m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Parse([]string{"--ratio=0", "--timeout=2m"}); err == nil {
+		t.Error("expected an error for ratio 0")
+	}
+	if err := f.Parse([]string{"--ratio=NaN"}); err == nil {
+		t.Error("expected an error for ratio NaN")
+	}
+	if err := f.Parse([]string{"--timeout=2m"}); err == nil {
+		t.Error("expected an error for timeout 2m")
+	}
+	if *ratio != 0.5 || *timeout != time.Second {
+		t.Errorf("expected defaults to be kept, got %v and %v", *ratio, *timeout)
+	}
+	if err := f.Parse([]string{"--ratio=1", "--timeout=30s"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if *ratio != 1 || *timeout != 30*time.Second {
+		t.Errorf("unexpected values %v and %v", *ratio, *timeout)
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("name", "", "name")
+	f.Int("workers", 4, "number of workers")
+
+	if err := f.SetRange("missing", Range{Min: "1"}); err == nil {
+		t.Error("expected an error for a missing flag")
+	}
+	if err := f.SetRange("name", Range{Min: "1"}); err == nil {
+		t.Error("expected an error for a string flag")
+	}
+	if err := f.SetRange("workers", Range{Min: "one"}); err == nil {
+		t.Error("expected an error for an invalid bound")
+	}
+
+	f.SetRange("workers", Range{Min: "1", Max: "256"})
+	err := f.Set("workers", "0")
+	expected := `invalid argument "0" for "--workers" flag: must be between 1 and 256`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestRangeInUsage(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int("workers", 4, "number of workers")
+	f.Float64("ratio", 0.5, "ratio")
+	f.SetRange("workers", Range{Min: "1", Max: "256"})
+	f.SetRange("ratio", Range{Min: "0", Max: "1", MinExclusive: true})
+
+	usage := f.FlagUsages()
+	for _, expected := range []string{
+		"number of workers (default 4) (must be between 1 and 256)",
+		"ratio (default 0.5) (must be greater than 0 and at most 1)",
+	} {
+		if !strings.Contains(usage, expected) {
+			t.Errorf("expected usage to contain %q, got:\n%s", expected, usage)
+		}
+	}
+}
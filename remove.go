@@ -0,0 +1,210 @@
+package pflag
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// ConflictPolicy tells AddFlagSetWithPolicy what to do with a flag whose
+// name, alias or shorthand is already used in the FlagSet.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip ignores the conflicting flag, like AddFlagSet.
+	ConflictSkip ConflictPolicy = iota
+	// ConflictOverride replaces the flag of the same name, and removes the
+	// other flags using its aliases or shorthand.
+	ConflictOverride
+	// ConflictError adds no flag and returns an error.
+	ConflictError
+)
+
+// RemoveFlag removes the named flag from the FlagSet, along with its
+// shorthand, its aliases, its observers and whether it was set. A flag which is the
+// replacement of a deprecated flag can't be removed.
+func (f *FlagSet) RemoveFlag(name string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	for _, other := range f.orderedFormal {
+		if other.Deprecation != nil && other.Deprecation.ReplacedBy == flag.Name {
+			return fmt.Errorf("flag %q is the replacement of deprecated flag %q", flag.Name, other.Name)
+		}
+	}
+	f.removeFlag(flag)
+	delete(f.flagObservers, NormalizedName(flag.Name))
+	for i, p := range f.positionals {
+		if p.flag == flag {
+			f.positionals = append(f.positionals[:i:i], f.positionals[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// ReplaceFlag replaces the flag of the same name with flag, which takes its
+// place in the order of definition and, if it was one, as a positional
+// argument. The new flag is not marked as set.
+func (f *FlagSet) ReplaceFlag(flag *Flag) error {
+	old := f.lookup(f.normalizeFlagName(flag.Name))
+	if old == nil {
+		return fmt.Errorf("flag %q does not exist", flag.Name)
+	}
+	if err := f.checkConflicts(flag, old); err != nil {
+		return err
+	}
+
+	index := 0
+	for i, other := range f.orderedFormal {
+		if other == old {
+			index = i
+		}
+	}
+	f.removeFlag(old)
+	f.AddFlag(flag)
+
+	// AddFlag appended the flag, move it where the old one was.
+	copy(f.orderedFormal[index+1:], f.orderedFormal[index:])
+	f.orderedFormal[index] = flag
+	if p := f.positionalArg(old); p != nil {
+		p.flag = flag
+	}
+	return nil
+}
+
+// AddFlagSetWithPolicy adds the flags of newSet to f, resolving the conflicts
+// with the flags already in f with the given policy. Flags of newSet already
+// added to f are left alone.
+func (f *FlagSet) AddFlagSetWithPolicy(newSet *FlagSet, policy ConflictPolicy) error {
+	if newSet == nil {
+		return nil
+	}
+
+	var flags []*Flag
+	var err error
+	newSet.VisitAll(func(flag *Flag) {
+		if err != nil || f.lookup(f.normalizeFlagName(flag.Name)) == flag {
+			return
+		}
+		if policy == ConflictError {
+			err = f.checkConflicts(flag, nil)
+		}
+		flags = append(flags, flag)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range flags {
+		conflicts := f.conflicts(flag)
+		if len(conflicts) > 0 && policy == ConflictSkip {
+			continue
+		}
+		if err := f.checkShorthand(flag); err != nil {
+			return err
+		}
+		var old *Flag
+		for _, c := range conflicts {
+			if c.Name == string(f.normalizeFlagName(flag.Name)) {
+				old = c
+			} else if err := f.RemoveFlag(c.Name); err != nil {
+				return err
+			}
+		}
+		if old != nil {
+			if err := f.ReplaceFlag(flag); err != nil {
+				return err
+			}
+		} else {
+			f.AddFlag(flag)
+		}
+	}
+	return nil
+}
+
+// conflicts returns the flags of f, other than flag itself, using the name,
+// an alias or the shorthand of flag.
+func (f *FlagSet) conflicts(flag *Flag) []*Flag {
+	var found []*Flag
+	add := func(c *Flag) {
+		if c == nil || c == flag {
+			return
+		}
+		for _, other := range found {
+			if other == c {
+				return
+			}
+		}
+		found = append(found, c)
+	}
+
+	add(f.lookup(f.normalizeFlagName(flag.Name)))
+	for _, alias := range flag.Aliases {
+		add(f.lookup(f.normalizeFlagName(alias)))
+	}
+	if utf8.RuneCountInString(flag.Shorthand) > 1 {
+		add(f.longShorthands[flag.Shorthand])
+	} else if flag.Shorthand != "" {
+		c, _ := utf8.DecodeRuneInString(flag.Shorthand)
+		add(f.shorthands[c])
+	}
+	return found
+}
+
+// checkConflicts returns an error if flag can't be added to f because of the
+// flags using its name, aliases or shorthand, other than ignored.
+func (f *FlagSet) checkConflicts(flag *Flag, ignored *Flag) error {
+	for _, c := range f.conflicts(flag) {
+		if c != ignored {
+			return fmt.Errorf("unable to add %q flag to %q flagset: it conflicts with %q flag", flag.Name, f.name, c.Name)
+		}
+	}
+	return f.checkShorthand(flag)
+}
+
+// checkShorthand returns an error if the shorthand of flag is too long for f.
+func (f *FlagSet) checkShorthand(flag *Flag) error {
+	if utf8.RuneCountInString(flag.Shorthand) > 1 && !f.multiLetter {
+		return fmt.Errorf("%q shorthand is more than one character", flag.Shorthand)
+	}
+	return nil
+}
+
+// removeFlag removes flag from all the indexes of f but the positionals.
+func (f *FlagSet) removeFlag(flag *Flag) {
+	delete(f.formal, NormalizedName(flag.Name))
+	f.orderedFormal = removeFromFlags(f.orderedFormal, flag)
+	f.sortedFormal = nil
+	if f.actual[NormalizedName(flag.Name)] == flag {
+		delete(f.actual, NormalizedName(flag.Name))
+	}
+	f.orderedActual = removeFromFlags(f.orderedActual, flag)
+	f.sortedActual = nil
+	for name, aliased := range f.aliases {
+		if aliased == flag {
+			delete(f.aliases, name)
+		}
+	}
+	for c, short := range f.shorthands {
+		if short == flag {
+			delete(f.shorthands, c)
+		}
+	}
+	for name, short := range f.longShorthands {
+		if short == flag {
+			delete(f.longShorthands, name)
+		}
+	}
+}
+
+// removeFromFlags returns flags without flag.
+func removeFromFlags(flags []*Flag, flag *Flag) []*Flag {
+	kept := flags[:0:0]
+	for _, other := range flags {
+		if other != flag {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
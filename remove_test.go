@@ -0,0 +1,169 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func setUpRemove() *FlagSet {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetWarningHandler(func(Warning) {})
+	f.SortFlags = false
+	f.BoolP("verbose", "v", false, "verbose")
+	f.IntP("port", "p", 80, "port")
+	f.AddAlias("port", "listen")
+	f.String("user", "", "user")
+	f.String("old-user", "", "user")
+	f.Deprecate("old-user", Deprecation{ReplacedBy: "user"})
+	return f
+}
+
+func flagNames(f *FlagSet) string {
+	var names []string
+	f.VisitAll(func(flag *Flag) {
+		names = append(names, flag.Name)
+	})
+	return strings.Join(names, ",")
+}
+
+func TestRemoveFlag(t *testing.T) {
+	f := setUpRemove()
+	if err := f.Parse([]string{"--port=8080"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	f.SortFlags = true
+	flagNames(f) // fill the sorted cache
+
+	if err := f.RemoveFlag("listen"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if f.Lookup("port") != nil || f.Lookup("listen") != nil || f.ShorthandLookup("p") != nil {
+		t.Error("expected the flag, its alias and its shorthand to be removed")
+	}
+	if f.NFlag() != 0 || f.Changed("port") {
+		t.Error("expected the flag to be removed from the set flags")
+	}
+	if names := flagNames(f); names != "old-user,user,verbose" {
+		t.Errorf("unexpected flags %s", names)
+	}
+	if err := f.Parse([]string{"--port=1"}); err == nil {
+		t.Error("expected removed flag to be unknown")
+	}
+
+	// The flag can be defined again.
+	f.IntP("port", "p", 80, "port")
+
+	if err := f.RemoveFlag("user"); err == nil {
+		t.Error("expected an error removing the replacement of a deprecated flag")
+	}
+	if err := f.RemoveFlag("bogus"); err == nil {
+		t.Error("expected an error removing an unknown flag")
+	}
+}
+
+func TestReplaceFlag(t *testing.T) {
+	f := setUpRemove()
+	if err := f.Parse([]string{"-p", "8080", "-v"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	g := NewFlagSet("other", ContinueOnError)
+	g.StringP("port", "P", "http", "port name")
+	if err := f.ReplaceFlag(g.Lookup("port")); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if names := flagNames(f); names != "verbose,port,user,old-user" {
+		t.Errorf("expected the flag to keep its place, got %s", names)
+	}
+	if f.ShorthandLookup("p") != nil || f.ShorthandLookup("P") != g.Lookup("port") || f.Lookup("listen") != nil {
+		t.Error("expected the shorthands and aliases to be updated")
+	}
+	if f.Changed("port") || f.NFlag() != 1 {
+		t.Error("expected the new flag not to be set")
+	}
+	if err := f.Parse([]string{"-P", "https"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if port, _ := f.GetString("port"); port != "https" {
+		t.Errorf("expected https, got %q", port)
+	}
+
+	h := NewFlagSet("other", ContinueOnError)
+	h.StringP("user", "v", "", "user")
+	if err := f.ReplaceFlag(h.Lookup("user")); err == nil {
+		t.Error("expected an error for a shorthand conflict")
+	}
+	if f.Lookup("user").Shorthand != "" {
+		t.Error("expected the flag not to be replaced on error")
+	}
+	h.String("bogus", "", "")
+	if err := f.ReplaceFlag(h.Lookup("bogus")); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestReplacePositional(t *testing.T) {
+	f, _, _ := setUpCopy()
+	g := NewFlagSet("other", ContinueOnError)
+	g.StringSlice("src", []string{}, "sources")
+	if err := f.ReplaceFlag(g.Lookup("src")); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.Parse([]string{"a,b", "c"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if src, _ := g.GetStringSlice("src"); len(src) != 2 {
+		t.Errorf("expected the new flag to be positional, got %v", src)
+	}
+}
+
+func TestAddFlagSetWithPolicy(t *testing.T) {
+	newSet := func() *FlagSet {
+		g := NewFlagSet("other", ContinueOnError)
+		g.SortFlags = false
+		g.String("port", "http", "port name")
+		g.BoolP("quiet", "v", false, "quiet")
+		g.Int("workers", 1, "workers")
+		return g
+	}
+
+	f := setUpRemove()
+	if err := f.AddFlagSetWithPolicy(newSet(), ConflictSkip); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if names := flagNames(f); names != "verbose,port,user,old-user,workers" {
+		t.Errorf("unexpected flags with ConflictSkip %s", names)
+	}
+	if f.Lookup("port").Value.Type() != "int" {
+		t.Error("expected the existing flag to be kept")
+	}
+
+	f = setUpRemove()
+	if err := f.AddFlagSetWithPolicy(newSet(), ConflictOverride); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if names := flagNames(f); names != "port,user,old-user,quiet,workers" {
+		t.Errorf("unexpected flags with ConflictOverride %s", names)
+	}
+	if f.Lookup("port").Value.Type() != "string" || f.ShorthandLookup("v") != f.Lookup("quiet") {
+		t.Error("expected the new flags to override the existing ones")
+	}
+
+	f = setUpRemove()
+	if err := f.AddFlagSetWithPolicy(newSet(), ConflictError); err == nil {
+		t.Error("expected an error with ConflictError")
+	}
+	if names := flagNames(f); names != "verbose,port,user,old-user" {
+		t.Errorf("expected no flag to be added on error, got %s", names)
+	}
+
+	g := newSet()
+	if err := f.AddFlagSetWithPolicy(g, ConflictOverride); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.AddFlagSetWithPolicy(g, ConflictError); err != nil {
+		t.Error("expected flags already added not to conflict, got", err)
+	}
+}
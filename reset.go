@@ -0,0 +1,163 @@
+package pflag
+
+import "net"
+
+// Reset restores every flag to its default value and clears what the last
+// Parse recorded: which flags were changed, the arguments and the unknown
+// flags. The FlagSet can then be parsed again as if it was new.
+//
+// Values of custom types are reset by passing DefValue to their Set method.
+func (f *FlagSet) Reset() error {
+	defer f.lock()()
+
+	var firstErr error
+	for _, flag := range f.formal {
+		if err := resetValue(flag); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		flag.Changed = false
+		flag.Source = Source{}
+	}
+	f.actual = nil
+	f.orderedActual = nil
+	f.args = nil
+	f.argsLenAtDash = -1
+	f.unknownFlags = nil
+	f.passthroughArgs = nil
+	f.parsed = false
+	return firstErr
+}
+
+// resetValue sets the value of flag back to its default.
+func resetValue(flag *Flag) error {
+	def := flag.DefValue
+	v := flag.Value
+	if s, ok := v.(*secretValue); ok {
+		def, v = s.defValue, s.Value
+	}
+
+	// Slices accumulate once set, so their state is restored directly.
+	switch v := v.(type) {
+	case *boolSliceValue:
+		val, err := boolSliceConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]bool), false
+	case *intSliceValue:
+		val, err := intSliceConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]int), false
+	case *uintSliceValue:
+		val, err := uintSliceConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]uint), false
+	case *ipSliceValue:
+		val, err := ipSliceConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]net.IP), false
+	case *stringSliceValue:
+		val, err := stringSliceConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]string), false
+	case *stringArrayValue:
+		val, err := stringArrayConv(def)
+		if err != nil {
+			return err
+		}
+		*v.value, v.changed = val.([]string), false
+	case *pathValue:
+		// The default need not pass the checks.
+		*v.value = def
+	case *noArgumentValue:
+		*v = false
+	case *secretFileValue:
+		v.path = def
+	default:
+		return v.Set(def)
+	}
+	return nil
+}
+
+// A Snapshot is the state of a FlagSet saved by FlagSet.Snapshot.
+type Snapshot struct {
+	flags           map[*Flag]flagState
+	actual          map[NormalizedName]*Flag
+	orderedActual   []*Flag
+	args            []string
+	argsLenAtDash   int
+	unknownFlags    []string
+	passthroughArgs []string
+	parsed          bool
+}
+
+// flagState is the state of a flag saved in a Snapshot.
+type flagState struct {
+	restore func()
+	changed bool
+	source  Source
+}
+
+// Snapshot saves the values of the flags and the parse state of the FlagSet,
+// so that they can be brought back with Restore.
+func (f *FlagSet) Snapshot() *Snapshot {
+	defer f.rlock()()
+
+	s := &Snapshot{
+		flags:           make(map[*Flag]flagState, len(f.formal)),
+		orderedActual:   append([]*Flag(nil), f.orderedActual...),
+		args:            append([]string(nil), f.args...),
+		argsLenAtDash:   f.argsLenAtDash,
+		unknownFlags:    append([]string(nil), f.unknownFlags...),
+		passthroughArgs: append([]string(nil), f.passthroughArgs...),
+		parsed:          f.parsed,
+	}
+	for _, flag := range f.formal {
+		s.flags[flag] = flagState{
+			restore: saveValue(flag.Value),
+			changed: flag.Changed,
+			source:  flag.Source,
+		}
+	}
+	if f.actual != nil {
+		s.actual = make(map[NormalizedName]*Flag, len(f.actual))
+		for name, flag := range f.actual {
+			s.actual[name] = flag
+		}
+	}
+	return s
+}
+
+// Restore brings the FlagSet back to the state saved by Snapshot. A snapshot
+// may be restored several times. Flags defined after the snapshot was taken
+// are left as they are, as are the values of flags of custom Value types.
+func (f *FlagSet) Restore(s *Snapshot) {
+	defer f.lock()()
+
+	for flag, state := range s.flags {
+		state.restore()
+		flag.Changed = state.changed
+		flag.Source = state.source
+	}
+	f.actual = nil
+	if s.actual != nil {
+		f.actual = make(map[NormalizedName]*Flag, len(s.actual))
+		for name, flag := range s.actual {
+			f.actual[name] = flag
+		}
+	}
+	f.orderedActual = append([]*Flag(nil), s.orderedActual...)
+	f.args = append([]string(nil), s.args...)
+	f.argsLenAtDash = s.argsLenAtDash
+	f.unknownFlags = append([]string(nil), s.unknownFlags...)
+	f.passthroughArgs = append([]string(nil), s.passthroughArgs...)
+	f.parsed = s.parsed
+}
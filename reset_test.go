@@ -0,0 +1,92 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func setUpReset() (f *FlagSet, ports *[]int, tags *[]string, names *[]string, token *string) {
+	f = NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetWarningHandler(func(Warning) {})
+	f.Bool("verbose", false, "verbose")
+	f.CountP("level", "l", "level")
+	f.Path("dir", "", PathMustExist, "directory")
+	ports = f.IntSlice("port", []int{80}, "ports")
+	tags = f.StringArray("tag", []string{}, "tags")
+	names = f.StringSlice("name", []string{"a", "b"}, "names")
+	token = f.String("token", "default", "token")
+	f.MarkSecret("token")
+	return
+}
+
+func TestReset(t *testing.T) {
+	f, ports, tags, names, token := setUpReset()
+	input := []string{"--verbose", "-ll", "--dir=.", "--port=1,2", "--tag=x", "--name=c", "--token=t", "arg"}
+	if err := f.Parse(input); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.Reset(); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	if f.Parsed() || f.NFlag() != 0 || f.NArg() != 0 {
+		t.Errorf("expected parse state to be cleared, got %v %d %d", f.Parsed(), f.NFlag(), f.NArg())
+	}
+	f.VisitAll(func(flag *Flag) {
+		if flag.Changed {
+			t.Errorf("expected flag %q to be unchanged", flag.Name)
+		}
+		if flag.Value.String() != flag.DefValue {
+			t.Errorf("expected flag %q to be %q, got %q", flag.Name, flag.DefValue, flag.Value)
+		}
+	})
+	if *token != "default" {
+		t.Errorf("expected the secret to be reset to its default, got %q", *token)
+	}
+
+	// Slices must replace their default again rather than append to it.
+	if err := f.Parse([]string{"--port=3", "--tag=y", "--name=d"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !reflect.DeepEqual(*ports, []int{3}) || !reflect.DeepEqual(*tags, []string{"y"}) || !reflect.DeepEqual(*names, []string{"d"}) {
+		t.Errorf("unexpected values after reparse %v %v %v", *ports, *tags, *names)
+	}
+	if f.NFlag() != 3 {
+		t.Errorf("expected 3 flags set, got %d", f.NFlag())
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	f, ports, tags, _, token := setUpReset()
+	if err := f.Parse([]string{"--port=1", "--token=t", "a"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	s := f.Snapshot()
+
+	for i := 0; i < 2; i++ {
+		if err := f.Parse([]string{"--port=2", "--tag=x", "--verbose", "--token=u", "b", "c"}); err != nil {
+			t.Fatal("expected no error; got", err)
+		}
+		f.Restore(s)
+
+		if !reflect.DeepEqual(*ports, []int{1}) || len(*tags) != 0 || *token != "t" {
+			t.Errorf("unexpected values after restore %v %v %q", *ports, *tags, *token)
+		}
+		if f.Changed("verbose") || f.Changed("tag") || !f.Changed("port") {
+			t.Error("expected changed flags to be restored")
+		}
+		if !reflect.DeepEqual(f.Args(), []string{"a"}) || f.NFlag() != 2 {
+			t.Errorf("unexpected parse state after restore %v %d", f.Args(), f.NFlag())
+		}
+	}
+
+	// A restored slice keeps accumulating from where it was.
+	if err := f.Parse([]string{"--port=5"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if !reflect.DeepEqual(*ports, []int{1, 5}) {
+		t.Errorf("unexpected ports %v", *ports)
+	}
+}
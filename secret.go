@@ -0,0 +1,149 @@
+package pflag
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// secretMask replaces the value of secret flags in usage messages and in
+// the String form of their Value.
+const secretMask = "******"
+
+// stdin is where secret file flags read from when given "-".
+var stdin io.Reader = os.Stdin
+
+// maskSecret returns secretMask, or the empty string if s is empty so that
+// unset secrets can still be told apart.
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return secretMask
+}
+
+// maskSource returns the source of the value of a secret flag, with the value
+// masked in its raw text, or the raw text left out if it can't be masked.
+func maskSource(src Source, value string) Source {
+	switch {
+	case value == "":
+	case src.Raw == value:
+		src.Raw = secretMask
+	case strings.HasSuffix(src.Raw, "="+value):
+		src.Raw = strings.TrimSuffix(src.Raw, value) + secretMask
+	case strings.Contains(src.Raw, value):
+		src.Raw = ""
+	}
+	return src
+}
+
+// -- secret Value
+type secretValue struct {
+	Value
+	defValue string // the unmasked default value
+	fileFlag string // name of the flag reading the value from a file, if any
+}
+
+func (s *secretValue) String() string { return maskSecret(s.Value.String()) }
+
+func (s *secretValue) save() func() { return saveValue(s.Value) }
+
+// unwrapValue returns the Value hidden behind a secret Value.
+func unwrapValue(v Value) Value {
+	if s, ok := v.(*secretValue); ok {
+		return s.Value
+	}
+	return v
+}
+
+// revealString returns the unmasked String form of v.
+func revealString(v Value) string {
+	return unwrapValue(v).String()
+}
+
+// MarkSecret marks a flag as holding sensitive data such as a password or a
+// token. Its default and current values are masked in usage messages and in
+// the String form of its Value, and a warning is printed when it is passed on
+// the command line. The typed getters and the variable bound to the flag
+// still hold the actual value.
+func (f *FlagSet) MarkSecret(name string) error {
+	flag := f.Lookup(name)
+	if flag == nil {
+		return fmt.Errorf("flag %q does not exist", name)
+	}
+	if flag.Secret {
+		return nil
+	}
+	markSecret(flag)
+	return nil
+}
+
+// markSecret hides the value of the flag behind a secret Value.
+func markSecret(flag *Flag) {
+	flag.Secret = true
+	flag.Value = &secretValue{Value: flag.Value, defValue: flag.DefValue}
+	if !flag.defaultIsZeroValue() {
+		flag.DefValue = maskSecret(flag.DefValue)
+	}
+}
+
+// -- secretFile Value
+type secretFileValue struct {
+	target  string
+	path    string
+	content string // read from path, until FlagSet.Set gives it to target
+}
+
+func (s *secretFileValue) Set(path string) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	s.content = strings.TrimSuffix(value, "\r")
+	s.path = path
+	return nil
+}
+
+func (s *secretFileValue) Type() string {
+	return "file"
+}
+
+func (s *secretFileValue) String() string { return s.path }
+
+func (s *secretFileValue) save() func() {
+	path := s.path
+	return func() { s.path = path }
+}
+
+// AddSecretFileFlag marks the named flag secret and defines a companion flag,
+// "--<name>-file", which reads the value of the flag from a file, or from the
+// standard input when given "-". A single trailing newline is removed.
+func (f *FlagSet) AddSecretFileFlag(name string) error {
+	if err := f.MarkSecret(name); err != nil {
+		return err
+	}
+	flag := f.Lookup(name)
+	value := &secretFileValue{target: flag.Name}
+	usage := fmt.Sprintf("read the value of --%s from a file (\"-\" for stdin)", flag.Name)
+	fileFlag := f.VarPF(value, flag.Name+"-file", "", usage)
+	flag.Value.(*secretValue).fileFlag = fileFlag.Name
+	return nil
+}
+
+// warnSecret issues a warning when a secret flag is given on the command line.
+func (f *FlagSet) warnSecret(flag *Flag) {
+	msg := fmt.Sprintf("Flag --%s is secret, passing it on the command line may expose it", flag.Name)
+	if sv, ok := flag.Value.(*secretValue); ok && sv.fileFlag != "" {
+		msg += fmt.Sprintf(", use --%s instead", sv.fileFlag)
+	}
+	f.warn(Warning{Kind: WarnSecret, Flag: flag, Message: msg})
+}
@@ -0,0 +1,243 @@
+package pflag
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setUpSecret(password *string) (*FlagSet, *bytes.Buffer) {
+	f := NewFlagSet("test", ContinueOnError)
+	out := new(bytes.Buffer)
+	f.SetOutput(out)
+	f.StringVar(password, "password", "hunter2", "the password")
+	f.MarkSecret("password")
+	return f, out
+}
+
+func TestSecretMasked(t *testing.T) {
+	var password string
+	f, _ := setUpSecret(&password)
+
+	usage := f.FlagUsages()
+	if strings.Contains(usage, "hunter2") {
+		t.Errorf("expected default to be masked in usage, got:\n%s", usage)
+	}
+	if !strings.Contains(usage, `(default "`+secretMask+`")`) {
+		t.Errorf("expected masked default in usage, got:\n%s", usage)
+	}
+
+	if err := f.Set("password", "s3cr3t"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	f.Visit(func(flag *Flag) {
+		if flag.Value.String() != secretMask {
+			t.Errorf("expected masked value in Visit, got %q", flag.Value.String())
+		}
+	})
+	if password != "s3cr3t" {
+		t.Errorf("expected bound variable to hold the value, got %q", password)
+	}
+	if v, _ := f.GetString("password"); v != "s3cr3t" {
+		t.Errorf("expected GetString to return the value, got %q", v)
+	}
+}
+
+func TestSecretZeroDefault(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int("pin", 0, "the pin")
+	f.Bool("insecure", false, "skip verification")
+	f.MarkSecret("pin")
+	f.MarkSecret("insecure")
+
+	if usage := f.FlagUsages(); strings.Contains(usage, "default") {
+		t.Errorf("expected no default for zero values, got:\n%s", usage)
+	}
+}
+
+func TestSecretErrorMasked(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.Int("pin", 0, "the pin")
+	f.MarkSecret("pin")
+
+	err := f.Set("pin", "12ab")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "12ab") {
+		t.Errorf("expected argument to be masked in error, got %q", err)
+	}
+}
+
+func TestSecretCommandLineWarning(t *testing.T) {
+	var password string
+	f, out := setUpSecret(&password)
+
+	if err := f.Parse([]string{"--password", "s3cr3t"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	expected := "Flag --password is secret, passing it on the command line may expose it\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	f.Set("password", "other")
+	if out.Len() != 0 {
+		t.Errorf("expected no warning for a programmatic Set, got %q", out.String())
+	}
+}
+
+func TestSecretField(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	out := new(bytes.Buffer)
+	f.SetOutput(out)
+	f.AddFlag(&Flag{
+		Name:     "token",
+		Usage:    "the token",
+		Value:    newStringValue("abc", new(string)),
+		DefValue: "abc",
+		Secret:   true,
+	})
+	if usage := f.FlagUsages(); strings.Contains(usage, "abc") {
+		t.Errorf("expected default to be masked in usage, got:\n%s", usage)
+	}
+	if err := f.Parse([]string{"--token=x"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if v := f.Lookup("token").Value.String(); v != secretMask {
+		t.Errorf("expected masked value, got %q", v)
+	}
+
+	// Setting the field after the flag was added must not break parsing.
+	f.String("key", "", "the key")
+	f.Lookup("key").Secret = true
+	if err := f.Parse([]string{"--key=x"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+}
+
+func TestSecretChecks(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.String("password", "", "the password")
+	f.MarkSecret("password")
+	f.SetValidator("password", func(v Value) error {
+		if len(v.String()) < 8 {
+			return errors.New("must be at least 8 characters")
+		}
+		return nil
+	})
+	if err := f.Set("password", "long enough"); err != nil {
+		t.Error("expected no error; got", err)
+	}
+	if err := f.Set("password", "short"); err == nil {
+		t.Error("expected an error for a short password")
+	}
+
+	f.Int("pin", 0, "the pin")
+	f.MarkSecret("pin")
+	if err := f.SetRange("pin", Range{Min: "1000", Max: "9999"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	f.Int("port", 0, "the port")
+	f.SetRange("port", Range{Min: "1", Max: "65535"})
+	f.MarkSecret("port")
+	for _, name := range []string{"pin", "port"} {
+		if err := f.Set(name, "1234"); err != nil {
+			t.Errorf("expected no error for %s; got %v", name, err)
+		}
+		if err := f.Set(name, "0"); err == nil {
+			t.Errorf("expected an error for %s out of range", name)
+		}
+	}
+}
+
+func TestSecretSourceMasked(t *testing.T) {
+	var password string
+	f, _ := setUpSecret(&password)
+	var changes []Change
+	f.AddObserver(func(c Change) { changes = append(changes, c) })
+
+	if err := f.Parse([]string{"--password=hunter2"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	f.SetWithSource("password", "s3cr3t", Source{Kind: SourceEnv, Location: "PASSWORD", Raw: "PASSWORD=s3cr3t"})
+	f.SetWithSource("password", "pw", Source{Kind: SourceConfig, Raw: `password: "pw"`})
+
+	expected := []string{"--password=" + secretMask, "PASSWORD=" + secretMask, ""}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d", len(expected), len(changes))
+	}
+	for i, raw := range expected {
+		if changes[i].Source.Raw != raw {
+			t.Errorf("expected raw text %q in change %d, got %q", raw, i, changes[i].Source.Raw)
+		}
+	}
+	if raw := f.Lookup("password").Source.Raw; raw != "" {
+		t.Errorf("expected raw text to be left out, got %q", raw)
+	}
+
+	f.Parse([]string{"--password=hunter2"})
+	if raw := f.Lookup("password").Source.Raw; raw != "--password="+secretMask {
+		t.Errorf("expected masked raw text, got %q", raw)
+	}
+}
+
+func TestSecretFileFlag(t *testing.T) {
+	file, err := ioutil.TempFile("", "pflag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("from-file\n")
+	file.Close()
+
+	var password string
+	f, out := setUpSecret(&password)
+	if err := f.AddSecretFileFlag("password"); err != nil {
+		t.Fatal("unexpected error from AddSecretFileFlag:", err)
+	}
+
+	if err := f.Parse([]string{"--password-file", file.Name()}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if password != "from-file" {
+		t.Errorf("expected password from file, got %q", password)
+	}
+	if !f.Changed("password") {
+		t.Errorf("expected password to be changed")
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no warning, got %q", out.String())
+	}
+
+	f.Parse([]string{"--password=s3cr3t"})
+	if !strings.Contains(out.String(), "use --password-file instead") {
+		t.Errorf("expected warning to suggest the file flag, got %q", out.String())
+	}
+
+	if err := f.Parse([]string{"--password-file", file.Name() + ".missing"}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestSecretFileFlagStdin(t *testing.T) {
+	defer func() { stdin = os.Stdin }()
+	stdin = strings.NewReader("from-stdin\r\n")
+
+	var password string
+	f, _ := setUpSecret(&password)
+	f.AddSecretFileFlag("password")
+
+	if err := f.Parse([]string{"--password-file=-"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if password != "from-stdin" {
+		t.Errorf("expected password from stdin, got %q", password)
+	}
+}
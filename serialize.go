@@ -0,0 +1,114 @@
+package pflag
+
+import "strings"
+
+// ToArgs renders the flags of the FlagSet as command line arguments which,
+// parsed by an identical FlagSet, reproduce their values. Only the flags
+// which were changed are rendered, unless all is true. This allows a program
+// to re-execute itself or to spawn workers with the same configuration.
+//
+// Flags are rendered as "--name=value", or "--name" when the value is the
+// NoOptDefVal of the flag. String arrays repeat the flag for each element,
+// and other slices are rendered as a single comma separated list. Secret
+// flags are rendered with their actual value. Positional arguments follow a
+// "--" terminator.
+//
+// Flags forwarding their value to a replacement flag with Deprecate, removed
+// flags and the flags reading secrets from files are left out, as well as
+// nil IPs, masks and networks, empty int and uint slices, and empty string
+// arrays, which cannot be given on the command line.
+func (f *FlagSet) ToArgs(all bool) []string {
+	var args []string
+	f.VisitAll(func(flag *Flag) {
+		if !all && !flag.Changed {
+			return
+		}
+		if f.positionalArg(flag) != nil {
+			return
+		}
+		if flag.Deprecation != nil && flag.Deprecation.ReplacedBy != "" {
+			return
+		}
+		if f.checkRemoved(flag) != nil {
+			return
+		}
+		args = append(args, flagArgs(flag)...)
+	})
+
+	var positionals []string
+	for _, p := range f.positionals {
+		if p.arity == ArgOptional && !p.flag.Changed {
+			continue
+		}
+		positionals = append(positionals, positionalArgs(p.flag)...)
+	}
+	if len(positionals) > 0 {
+		args = append(args, "--")
+		args = append(args, positionals...)
+	}
+	return args
+}
+
+// flagArgs renders the value of flag as command line arguments.
+func flagArgs(flag *Flag) []string {
+	var args []string
+	for _, value := range setValues(flag.Value) {
+		if flag.NoOptDefVal != "" && value == flag.NoOptDefVal {
+			args = append(args, "--"+flag.Name)
+		} else {
+			args = append(args, "--"+flag.Name+"="+value)
+		}
+	}
+	return args
+}
+
+// positionalArgs renders the value of the positional argument flag.
+func positionalArgs(flag *Flag) []string {
+	return setValues(flag.Value)
+}
+
+// setValues returns the strings to pass to successive calls of Set to
+// reproduce the value v from its default, or nil if there is none.
+func setValues(v Value) []string {
+	switch v := unwrapValue(v).(type) {
+	case *secretFileValue:
+		return nil
+	case *stringArrayValue:
+		return append([]string(nil), *v.value...)
+	case *intSliceValue:
+		if len(*v.value) == 0 {
+			return nil
+		}
+	case *uintSliceValue:
+		if len(*v.value) == 0 {
+			return nil
+		}
+	case *ipValue:
+		if len(*v) == 0 {
+			return nil
+		}
+	case *ipMaskValue:
+		if len(*v) == 0 {
+			return nil
+		}
+	case *ipNetValue:
+		if v.IP == nil {
+			return nil
+		}
+	case *noArgumentValue:
+		if !*v {
+			return nil
+		}
+	case *countValue:
+		// Setting -1 increments the count instead.
+		if *v == -1 {
+			return []string{"-2", "-1"}
+		}
+	}
+
+	value := revealString(v)
+	if strings.HasSuffix(v.Type(), "Slice") {
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	}
+	return []string{value}
+}
@@ -0,0 +1,293 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// roundTrip sets the flag "v" of a FlagSet with set, renders it with ToArgs
+// and parses the arguments with a FlagSet defined the same way. It reports
+// whether both flags have the same value.
+func roundTrip(t *testing.T, define func(f *FlagSet), set func(f *FlagSet)) bool {
+	a := NewFlagSet("a", ContinueOnError)
+	define(a)
+	set(a)
+	a.Lookup("v").Changed = true
+	args := a.ToArgs(false)
+
+	b := NewFlagSet("b", ContinueOnError)
+	b.SetOutput(ioutil.Discard)
+	define(b)
+	if err := b.Parse(args); err != nil {
+		t.Logf("parsing %q: %v", args, err)
+		return false
+	}
+	if want, got := a.Lookup("v").Value.String(), b.Lookup("v").Value.String(); want != got {
+		t.Logf("parsing %q: expected %s, got %s", args, want, got)
+		return false
+	}
+	return true
+}
+
+func checkRoundTrip(t *testing.T, name string, fn interface{}) {
+	if err := quick.Check(fn, nil); err != nil {
+		t.Errorf("%s: %v", name, err)
+	}
+}
+
+func TestToArgsRoundTrip(t *testing.T) {
+	checkRoundTrip(t, "bool", func(x bool) bool {
+		var p *bool
+		return roundTrip(t, func(f *FlagSet) { p = f.Bool("v", false, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "bool with true default", func(x bool) bool {
+		var p *bool
+		return roundTrip(t, func(f *FlagSet) { p = f.Bool("v", true, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "count", func(x int) bool {
+		var p *int
+		return roundTrip(t, func(f *FlagSet) { p = f.Count("v", "") }, func(f *FlagSet) { *p = x % 4 })
+	})
+	checkRoundTrip(t, "duration", func(x int64) bool {
+		var p *time.Duration
+		return roundTrip(t, func(f *FlagSet) { p = f.Duration("v", 0, "") }, func(f *FlagSet) { *p = time.Duration(x) })
+	})
+	checkRoundTrip(t, "float32", func(x float32) bool {
+		var p *float32
+		return roundTrip(t, func(f *FlagSet) { p = f.Float32("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "float64", func(x float64) bool {
+		var p *float64
+		return roundTrip(t, func(f *FlagSet) { p = f.Float64("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "int", func(x int) bool {
+		var p *int
+		return roundTrip(t, func(f *FlagSet) { p = f.Int("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "int8", func(x int8) bool {
+		var p *int8
+		return roundTrip(t, func(f *FlagSet) { p = f.Int8("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "int32", func(x int32) bool {
+		var p *int32
+		return roundTrip(t, func(f *FlagSet) { p = f.Int32("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "int64", func(x int64) bool {
+		var p *int64
+		return roundTrip(t, func(f *FlagSet) { p = f.Int64("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uint", func(x uint) bool {
+		var p *uint
+		return roundTrip(t, func(f *FlagSet) { p = f.Uint("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uint8", func(x uint8) bool {
+		var p *uint8
+		return roundTrip(t, func(f *FlagSet) { p = f.Uint8("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uint16", func(x uint16) bool {
+		var p *uint16
+		return roundTrip(t, func(f *FlagSet) { p = f.Uint16("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uint32", func(x uint32) bool {
+		var p *uint32
+		return roundTrip(t, func(f *FlagSet) { p = f.Uint32("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uint64", func(x uint64) bool {
+		var p *uint64
+		return roundTrip(t, func(f *FlagSet) { p = f.Uint64("v", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "string", func(x string) bool {
+		var p *string
+		return roundTrip(t, func(f *FlagSet) { p = f.String("v", "default", "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "string with NoOptDefVal", func(x string) bool {
+		var p *string
+		return roundTrip(t, func(f *FlagSet) {
+			p = f.String("v", "", "")
+			f.Lookup("v").NoOptDefVal = "auto"
+		}, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "secret string", func(x string) bool {
+		var p *string
+		return roundTrip(t, func(f *FlagSet) {
+			p = f.String("v", "", "")
+			f.MarkSecret("v")
+			f.SetWarningHandler(func(Warning) {})
+		}, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "path", func(x string) bool {
+		var p *string
+		return roundTrip(t, func(f *FlagSet) { p = f.Path("v", "", 0, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "ip", func(x [16]byte, v4 bool) bool {
+		ip := net.IP(x[:])
+		if v4 {
+			ip = net.IPv4(x[0], x[1], x[2], x[3])
+		}
+		var p *net.IP
+		return roundTrip(t, func(f *FlagSet) { p = f.IP("v", nil, "") }, func(f *FlagSet) { *p = ip })
+	})
+	checkRoundTrip(t, "ipMask", func(ones uint8) bool {
+		var p *net.IPMask
+		mask := net.CIDRMask(int(ones%33), 32)
+		return roundTrip(t, func(f *FlagSet) { p = f.IPMask("v", nil, "") }, func(f *FlagSet) { *p = mask })
+	})
+	checkRoundTrip(t, "ipNet", func(x [4]byte, ones uint8) bool {
+		mask := net.CIDRMask(int(ones%33), 32)
+		n := net.IPNet{IP: net.IP(x[:]).Mask(mask), Mask: mask}
+		var p *net.IPNet
+		return roundTrip(t, func(f *FlagSet) { p = f.IPNet("v", net.IPNet{}, "") }, func(f *FlagSet) { *p = n })
+	})
+	checkRoundTrip(t, "boolSlice", func(x []bool) bool {
+		var p *[]bool
+		return roundTrip(t, func(f *FlagSet) { p = f.BoolSlice("v", []bool{}, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "intSlice", func(x []int) bool {
+		var p *[]int
+		return roundTrip(t, func(f *FlagSet) { p = f.IntSlice("v", []int{}, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "uintSlice", func(x []uint) bool {
+		var p *[]uint
+		return roundTrip(t, func(f *FlagSet) { p = f.UintSlice("v", []uint{}, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "ipSlice", func(x [][4]byte) bool {
+		ips := []net.IP{}
+		for _, b := range x {
+			ips = append(ips, net.IPv4(b[0], b[1], b[2], b[3]))
+		}
+		var p *[]net.IP
+		return roundTrip(t, func(f *FlagSet) { p = f.IPSlice("v", []net.IP{}, "") }, func(f *FlagSet) { *p = ips })
+	})
+	checkRoundTrip(t, "stringSlice", func(x []string) bool {
+		var p *[]string
+		return roundTrip(t, func(f *FlagSet) { p = f.StringSlice("v", []string{}, "") }, func(f *FlagSet) { *p = x })
+	})
+	checkRoundTrip(t, "stringArray", func(x []string) bool {
+		var p *[]string
+		return roundTrip(t, func(f *FlagSet) { p = f.StringArray("v", []string{}, "") }, func(f *FlagSet) { *p = x })
+	})
+}
+
+// csvString generates strings made of characters that matter to the CSV
+// encoding of string slices.
+type csvString string
+
+func (csvString) Generate(r *rand.Rand, size int) reflect.Value {
+	const chars = "ab ,\"'\n\t-=[]\\é"
+	runes := []rune(chars)
+	s := make([]rune, r.Intn(size+1))
+	for i := range s {
+		s[i] = runes[r.Intn(len(runes))]
+	}
+	return reflect.ValueOf(csvString(s))
+}
+
+func TestToArgsRoundTripQuoting(t *testing.T) {
+	checkRoundTrip(t, "stringSlice", func(x []csvString) bool {
+		s := []string{}
+		for _, e := range x {
+			s = append(s, string(e))
+		}
+		var p *[]string
+		return roundTrip(t, func(f *FlagSet) { p = f.StringSlice("v", []string{}, "") }, func(f *FlagSet) { *p = s })
+	})
+	checkRoundTrip(t, "stringArray", func(x []csvString) bool {
+		s := []string{}
+		for _, e := range x {
+			s = append(s, string(e))
+		}
+		var p *[]string
+		return roundTrip(t, func(f *FlagSet) { p = f.StringArray("v", []string{}, "") }, func(f *FlagSet) { *p = s })
+	})
+}
+
+func TestToArgs(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.SetWarningHandler(func(Warning) {})
+	f.BoolP("verbose", "v", false, "")
+	f.CountP("level", "l", "")
+	f.Int("port", 80, "")
+	f.StringArray("tag", []string{}, "")
+	f.String("user", "", "")
+	f.String("old-user", "", "")
+	f.Deprecate("old-user", Deprecation{ReplacedBy: "user"})
+	f.StringSlice("src", []string{}, "")
+	f.MarkPositional("src", ArgVariadic)
+	f.String("dest", "", "")
+	f.MarkPositional("dest", ArgRequired)
+
+	input := []string{"-vll", "--tag=a", "--old-user", "bob", "--tag", "b", "x,y", "--", "-z", "-out"}
+	if err := f.Parse(input); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	expected := "--level=2 --tag=a --tag=b --user=bob --verbose -- x,y,-z -out"
+	if got := strings.Join(f.ToArgs(false), " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	expected = "--level=2 --port=80 --tag=a --tag=b --user=bob --verbose -- x,y,-z -out"
+	if got := strings.Join(f.ToArgs(true), " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+// defineAll defines a flag of each built-in type, with its zero default.
+func defineAll(f *FlagSet) {
+	f.Bool("bool", false, "")
+	f.Count("count", "")
+	f.Duration("duration", 0, "")
+	f.Float32("float32", 0, "")
+	f.Float64("float64", 0, "")
+	f.Int("int", 0, "")
+	f.Int8("int8", 0, "")
+	f.Int32("int32", 0, "")
+	f.Int64("int64", 0, "")
+	f.Uint("uint", 0, "")
+	f.Uint8("uint8", 0, "")
+	f.Uint16("uint16", 0, "")
+	f.Uint32("uint32", 0, "")
+	f.Uint64("uint64", 0, "")
+	f.String("string", "", "")
+	f.Path("path", "", 0, "")
+	f.IP("ip", nil, "")
+	f.IPMask("mask", nil, "")
+	f.IPNet("net", net.IPNet{}, "")
+	f.BoolSlice("bools", []bool{}, "")
+	f.IntSlice("ints", []int{}, "")
+	f.UintSlice("uints", []uint{}, "")
+	f.IPSlice("ips", []net.IP{}, "")
+	f.StringSlice("strings", []string{}, "")
+	f.StringArray("array", []string{}, "")
+	f.String("secret", "", "")
+	f.MarkSecret("secret")
+	f.AddSecretFileFlag("secret")
+	f.String("old", "", "")
+	f.Deprecate("old", Deprecation{RemovedIn: "v2"})
+	f.SetVersion("v3")
+	f.SetWarningHandler(func(Warning) {})
+}
+
+func TestToArgsAllDefaults(t *testing.T) {
+	a := NewFlagSet("a", ContinueOnError)
+	defineAll(a)
+	args := a.ToArgs(true)
+
+	b := NewFlagSet("b", ContinueOnError)
+	b.SetOutput(ioutil.Discard)
+	defineAll(b)
+	if err := b.Parse(args); err != nil {
+		t.Fatalf("parsing %q: %v", args, err)
+	}
+	a.VisitAll(func(flag *Flag) {
+		if want, got := flag.Value.String(), b.Lookup(flag.Name).Value.String(); want != got {
+			t.Errorf("parsing %q: expected %s to be %s, got %s", args, flag.Name, want, got)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SourceKind tells where the value of a flag came from.
+type SourceKind int
+
+const (
+	// SourceDefault means the flag was not set and has its default value.
+	SourceDefault SourceKind = iota
+	// SourceCommandLine means the value was parsed from the arguments.
+	SourceCommandLine
+	// SourceEnv means the value came from an environment variable.
+	SourceEnv
+	// SourceConfig means the value came from a configuration file.
+	SourceConfig
+	// SourceProgram means the value was set by the program with Set.
+	SourceProgram
+)
+
+// String returns the name of the kind of source, e.g. "command line".
+func (k SourceKind) String() string {
+	switch k {
+	case SourceDefault:
+		return "default"
+	case SourceCommandLine:
+		return "command line"
+	case SourceEnv:
+		return "environment"
+	case SourceConfig:
+		return "config file"
+	case SourceProgram:
+		return "program"
+	}
+	return fmt.Sprintf("SourceKind(%d)", int(k))
+}
+
+// Source describes where the value of a flag came from. It is recorded by
+// Parse, Set and SetWithSource.
+type Source struct {
+	Kind SourceKind
+	// Arg is the index, in the arguments passed to Parse, of the argument
+	// naming the flag, or holding the value of a positional argument. It is
+	// only meaningful for SourceCommandLine.
+	Arg int
+	// Location is where the value was read from, such as the name of an
+	// environment variable or "file:line" for a configuration file.
+	Location string
+	// Raw is the text the value was read from, such as "--port=80". The
+	// value of a secret flag is masked in it.
+	Raw string
+}
+
+// String describes the source, e.g. `command line argument 2 "--port=80"`.
+func (s Source) String() string {
+	desc := s.Kind.String()
+	switch {
+	case s.Kind == SourceCommandLine && s.Arg >= 0:
+		desc += fmt.Sprintf(" argument %d", s.Arg)
+	case s.Kind == SourceEnv && s.Location != "":
+		desc += " variable " + s.Location
+	case s.Location != "":
+		desc += " " + s.Location
+	}
+	if s.Raw != "" {
+		desc += fmt.Sprintf(" %q", s.Raw)
+	}
+	return desc
+}
+
+// ProvenanceReport returns a string listing the value of every flag of the
+// FlagSet and where it came from. The raw text of secret flags is left out.
+func (f *FlagSet) ProvenanceReport() string {
+	buf := new(bytes.Buffer)
+
+	var names, sources []string
+	maxlen := 0
+	f.VisitAll(func(flag *Flag) {
+		name := fmt.Sprintf("  --%s=%s", flag.Name, flag.Value)
+		if w := displayWidth(name); w > maxlen {
+			maxlen = w
+		}
+		src := flag.Source
+		if flag.Secret {
+			src.Raw = ""
+		}
+		names = append(names, name)
+		sources = append(sources, src.String())
+	})
+
+	for i, name := range names {
+		fmt.Fprintln(buf, name, strings.Repeat(" ", maxlen-displayWidth(name)+1), sources[i])
+	}
+	return buf.String()
+}
+
+// PrintProvenance prints, to standard error unless configured otherwise, the
+// provenance report of the flags of the FlagSet.
+func (f *FlagSet) PrintProvenance() {
+	fmt.Fprint(f.out(), f.ProvenanceReport())
+}
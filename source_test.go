@@ -0,0 +1,86 @@
+package pflag
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.IntP("port", "p", 80, "port")
+	f.String("host", "localhost", "host")
+	f.BoolP("verbose", "v", false, "verbose")
+	f.String("user", "", "user")
+	f.String("name", "", "name")
+	f.MarkPositional("name", ArgRequired)
+	f.String("old-user", "", "user")
+	f.Deprecate("old-user", Deprecation{ReplacedBy: "user"})
+	f.SetWarningHandler(func(Warning) {})
+
+	if err := f.Parse([]string{"-vp", "8080", "--old-user=bob", "alice"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if err := f.SetWithSource("host", "example.com", Source{Kind: SourceEnv, Location: "HOST", Raw: "HOST=example.com"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	expected := map[string]Source{
+		"port":     {Kind: SourceCommandLine, Arg: 0, Raw: "-vp"},
+		"verbose":  {Kind: SourceCommandLine, Arg: 0, Raw: "-vp"},
+		"user":     {Kind: SourceCommandLine, Arg: 2, Raw: "--old-user=bob"},
+		"old-user": {Kind: SourceCommandLine, Arg: 2, Raw: "--old-user=bob"},
+		"name":     {Kind: SourceCommandLine, Arg: 3, Raw: "alice"},
+		"host":     {Kind: SourceEnv, Location: "HOST", Raw: "HOST=example.com"},
+	}
+	for name, src := range expected {
+		if got := f.Lookup(name).Source; got != src {
+			t.Errorf("expected source %v for %s, got %v", src, name, got)
+		}
+	}
+
+	f.Set("port", "9090")
+	if got := f.Lookup("port").Source; got.Kind != SourceProgram {
+		t.Errorf("expected source of Set to be the program, got %v", got)
+	}
+	if got := f.Lookup("verbose").Source.String(); got != `command line argument 0 "-vp"` {
+		t.Errorf("unexpected description %q", got)
+	}
+
+	if err := f.Parse([]string{"--", "-carol"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	src := Source{Kind: SourceCommandLine, Arg: 1, Raw: "-carol"}
+	if got := f.Lookup("name").Source; got != src {
+		t.Errorf("expected source %v after --, got %v", src, got)
+	}
+}
+
+func TestProvenanceReport(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.Int("port", 80, "port")
+	f.String("token", "", "token")
+	f.MarkSecret("token")
+	f.SetWarningHandler(func(Warning) {})
+	f.SetWithSource("port", "8080", Source{Kind: SourceConfig, Location: "app.conf:3", Raw: "port = 8080"})
+	if err := f.Parse([]string{"--token=hunter2"}); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+
+	expected := `  --port=8080      config file app.conf:3 "port = 8080"
+  --token=******   command line argument 0
+`
+	if got := f.ProvenanceReport(); got != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, got)
+	}
+
+	f.String("city", "", "city")
+	f.Set("city", "東京都")
+	expected = `  --city=東京都    program
+  --port=8080      config file app.conf:3 "port = 8080"
+  --token=******   command line argument 0
+`
+	if got := f.ProvenanceReport(); got != expected {
+		t.Errorf("expected report\n%s\ngot\n%s", expected, got)
+	}
+}
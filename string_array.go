@@ -23,6 +23,11 @@ func (s *stringArrayValue) Set(val string) error {
 	return nil
 }
 
+func (s *stringArrayValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 func (s *stringArrayValue) Type() string {
 	return "stringArray"
 }
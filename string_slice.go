@@ -53,6 +53,11 @@ func (s *stringSliceValue) Set(val string) error {
 	return nil
 }
 
+func (s *stringSliceValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 func (s *stringSliceValue) Type() string {
 	return "stringSlice"
 }
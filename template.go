@@ -0,0 +1,230 @@
+package pflag
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// DefaultUsageTemplate is the text/template rendering flag usages in the
+// default layout:
+//
+//	-x, --name type   usage (default ...)
+//
+// It is executed with a UsageData and defines the "names" and "usage"
+// templates, which render the two columns of a flag.
+const DefaultUsageTemplate = `{{define "names" -}}
+{{if .Shorthand}}  {{style "name" (print "-" .Shorthand)}}, {{else}}      {{end -}}
+{{style "name" (print "--" .Name)}}{{range .Aliases}}, {{style "name" (print "--" .)}}{{end -}}
+{{if .NoOptDefVal}}{{style "default" .OptionalArg}}{{else if .Varname}} {{style "type" .Varname}}{{end -}}
+{{end -}}
+
+{{define "usage" -}}
+{{.Usage}}{{if .Default}} (default {{style "default" .Default}}){{end}}{{if .Range}} (must be {{.Range}}){{end -}}
+{{end -}}
+
+{{$width := column "names" .Flags -}}
+{{range .Flags -}}
+{{pad $width (include "names" .)}}   {{wrap (add $width 3) $.Cols (include "usage" .)}}
+{{end -}}
+`
+
+// UsageData is the data a usage template is executed with.
+type UsageData struct {
+	// Flags are the flags to list, in order. Hidden and deprecated flags and
+	// positional arguments are left out.
+	Flags []*FlagUsage
+	// Deprecated are the deprecated flags which are not hidden.
+	Deprecated []*FlagUsage
+	// Cols is the width to wrap to, 0 for no wrapping.
+	Cols int
+}
+
+// FlagUsage describes a flag for usage templates.
+type FlagUsage struct {
+	// Flag is the described flag.
+	Flag *Flag
+	// Name is the name of the flag.
+	Name string
+	// Shorthand is the shorthand of the flag, empty if it has none or if it
+	// is deprecated.
+	Shorthand string
+	// Aliases are the other long names of the flag.
+	Aliases []string
+	// Varname is the name of the flag's argument, as returned by
+	// UnquoteUsage: the back-quoted word of the usage or the type name.
+	Varname string
+	// Usage is the help message, with the back quotes removed.
+	Usage string
+	// Type is the type of the flag's value.
+	Type string
+	// Default is the default value as shown in the default layout, quoted for
+	// strings. It is empty if the default is the zero value.
+	Default string
+	// NoOptDefVal is the value of the flag given without argument.
+	NoOptDefVal string
+	// OptionalArg shows NoOptDefVal as in the default layout, e.g.
+	// `[="auto"]`. It is empty for bool flags whose NoOptDefVal is "true".
+	OptionalArg string
+	// Range is the range the value must lie within, empty if there is none.
+	Range string
+	// Deprecated is the deprecation message of the flag.
+	Deprecated string
+	// ShorthandDeprecated is the deprecation message of the shorthand.
+	ShorthandDeprecated string
+	// Annotations are the annotations of the flag.
+	Annotations map[string][]string
+	// Group is the title of the group of the flag, see AddGroup.
+	Group string
+}
+
+func newFlagUsage(flag *Flag) *FlagUsage {
+	varname, usage := UnquoteUsage(flag)
+	u := &FlagUsage{
+		Flag:                flag,
+		Name:                flag.Name,
+		Aliases:             flag.Aliases,
+		Varname:             varname,
+		Usage:               usage,
+		Type:                flag.Value.Type(),
+		NoOptDefVal:         flag.NoOptDefVal,
+		Deprecated:          flag.Deprecated,
+		ShorthandDeprecated: flag.ShorthandDeprecated,
+		Annotations:         flag.Annotations,
+		Group:               flag.Group,
+	}
+	if flag.ShorthandDeprecated == "" {
+		u.Shorthand = flag.Shorthand
+	}
+	if flag.NoOptDefVal != "" {
+		switch u.Type {
+		case "string":
+			u.OptionalArg = fmt.Sprintf("[=\"%s\"]", flag.NoOptDefVal)
+		case "bool":
+			if flag.NoOptDefVal != "true" {
+				u.OptionalArg = fmt.Sprintf("[=%s]", flag.NoOptDefVal)
+			}
+		default:
+			u.OptionalArg = fmt.Sprintf("[=%s]", flag.NoOptDefVal)
+		}
+	}
+	if !flag.defaultIsZeroValue() {
+		if u.Type == "string" {
+			u.Default = fmt.Sprintf("%q", flag.DefValue)
+		} else {
+			u.Default = flag.DefValue
+		}
+	}
+	if flag.Range != nil {
+		u.Range = flag.Range.String()
+	}
+	return u
+}
+
+// defaultUsageTemplate is DefaultUsageTemplate, parsed.
+var defaultUsageTemplate = template.Must(parseUsageTemplate(DefaultUsageTemplate))
+
+// styles are the ANSI sequences coloring the parts of usages, see SetColor.
+var styles = map[string]string{
+	"name":    "\x1b[1m",  // bold
+	"type":    "\x1b[36m", // cyan
+	"default": "\x1b[32m", // green
+}
+
+// parseUsageTemplate parses a usage template with its helper functions.
+func parseUsageTemplate(text string) (*template.Template, error) {
+	t := template.New("flags")
+	return t.Funcs(usageFuncs(t, false)).Parse(text)
+}
+
+// executeUsageTemplate executes a usage template, with the style function
+// coloring its text if color is true.
+func executeUsageTemplate(t *template.Template, w io.Writer, data UsageData, color bool) error {
+	if color {
+		c, err := t.Clone()
+		if err != nil {
+			return err
+		}
+		t = c.Funcs(usageFuncs(c, true))
+	}
+	return t.Execute(w, data)
+}
+
+// usageFuncs returns the helper functions of the usage template t.
+func usageFuncs(t *template.Template, color bool) template.FuncMap {
+	include := func(name string, data interface{}) (string, error) {
+		buf := new(bytes.Buffer)
+		err := t.ExecuteTemplate(buf, name, data)
+		return buf.String(), err
+	}
+	return template.FuncMap{
+		"include": include,
+		"column": func(name string, flags []*FlagUsage) (int, error) {
+			width := 0
+			for _, flag := range flags {
+				s, err := include(name, flag)
+				if err != nil {
+					return 0, err
+				}
+				if w := displayWidth(s); w > width {
+					width = w
+				}
+			}
+			return width, nil
+		},
+		"pad": func(width int, s string) string {
+			if w := displayWidth(s); w < width {
+				return s + strings.Repeat(" ", width-w)
+			}
+			return s
+		},
+		"wrap": func(indent, cols int, s string) string {
+			return wrap(indent, cols, s)
+		},
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"style": func(kind, s string) (string, error) {
+			style, ok := styles[kind]
+			if !ok {
+				return "", fmt.Errorf("unknown style %q", kind)
+			}
+			if !color || s == "" {
+				return s, nil
+			}
+			return style + s + "\x1b[0m", nil
+		},
+	}
+}
+
+// SetUsageTemplate sets the text/template rendering flag usages, in
+// FlagUsages, PrintDefaults and the default usage message. The template is
+// executed with a UsageData, and may use these functions besides the
+// text/template builtins:
+//
+//	include name data   renders the named template to a string
+//	column name flags   the width of the widest rendering of the named
+//	                    template for the flags, to align a column
+//	pad width s         s padded with spaces to width
+//	wrap indent cols s  s wrapped to cols columns, lines after the first
+//	                    indented by indent spaces (cols 0 for no wrapping)
+//	add a b             a + b
+//	style kind s        s colored as a "name", "type" or "default" when
+//	                    the output is colorized, see SetColor
+//
+// See DefaultUsageTemplate for the default layout. An empty text restores
+// the default.
+func (f *FlagSet) SetUsageTemplate(text string) error {
+	if text == "" {
+		f.usageTemplate = nil
+		return nil
+	}
+	t, err := parseUsageTemplate(text)
+	if err != nil {
+		return err
+	}
+	f.usageTemplate = t
+	return nil
+}
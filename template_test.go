@@ -0,0 +1,87 @@
+package pflag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetUsageTemplate(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.StringP("listen", "l", ":80", "`address` to listen on")
+	f.String("color", "", "colorize the output")
+	f.Lookup("color").NoOptDefVal = "auto"
+	f.SetAnnotation("listen", "env", []string{"LISTEN"})
+	f.Bool("old", false, "old flag")
+	f.MarkDeprecated("old", "use --new")
+
+	defaults := f.FlagUsages()
+	err := f.SetUsageTemplate(`{{range .Flags -}}
+{{.Name}}{{with .Shorthand}} (-{{.}}){{end}}{{with .Varname}} <{{.}}>{{end}}: {{.Usage}}
+{{- with .Default}} [default: {{.}}]{{end}}
+{{- with .OptionalArg}} {{.}}{{end}}
+{{- with index .Annotations "env"}} ${{index . 0}}{{end}}
+{{end -}}
+{{range .Deprecated}}{{.Name}}: {{.Deprecated}}
+{{end -}}
+`)
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	expected := `color <string>: colorize the output [="auto"]
+listen (-l) <address>: address to listen on [default: ":80"] $LISTEN
+old: use --new
+`
+	if got := f.FlagUsages(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+
+	c, err := f.Clone()
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if got := c.FlagUsages(); got != expected {
+		t.Errorf("expected the clone to keep the template, got\n%s", got)
+	}
+
+	if err := f.SetUsageTemplate(""); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if got := f.FlagUsages(); got != defaults {
+		t.Errorf("expected the default layout\n%s\ngot\n%s", defaults, got)
+	}
+}
+
+func TestUsageTemplateHelpers(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("a", "", "first flag")
+	f.String("long-name", "", "second flag with a usage long enough to be wrapped")
+
+	err := f.SetUsageTemplate(`{{define "left"}}--{{.Name}}{{end -}}
+{{$w := column "left" .Flags}}{{range .Flags -}}
+{{pad $w (include "left" .)}} | {{wrap (add $w 3) $.Cols .Usage}}
+{{end}}`)
+	if err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	expected := `--a         | first flag
+--long-name | second flag with a usage long enough to
+              be wrapped
+`
+	if got := f.FlagUsagesWrapped(60); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestUsageTemplateErrors(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("a", "", "a")
+	if err := f.SetUsageTemplate("{{range .Flags}}"); err == nil {
+		t.Error("expected an error for a malformed template")
+	}
+	if err := f.SetUsageTemplate("{{range .Flags}}{{.Bogus}}{{end}}"); err != nil {
+		t.Fatal("expected no error; got", err)
+	}
+	if got := f.FlagUsages(); !strings.Contains(got, "error rendering flag usages") {
+		t.Errorf("expected the execution error in the usages, got %q", got)
+	}
+}
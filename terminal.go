@@ -0,0 +1,54 @@
+package pflag
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// TerminalWidth returns the number of columns to wrap usages written to w
+// to: the COLUMNS environment variable if it is set, else the width of the
+// terminal if w is one, else 0 for no wrapping.
+func TerminalWidth(w io.Writer) int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	cols, _ := writerColumns(w)
+	return cols
+}
+
+// writerColumns returns the width of the terminal w writes to, and false if
+// w is not a terminal.
+func writerColumns(w io.Writer) (int, bool) {
+	file, ok := w.(interface {
+		Fd() uintptr
+	})
+	if !ok {
+		return 0, false
+	}
+	return terminalColumns(file.Fd())
+}
+
+// useColor tells whether usages written to w may be colorized: w must be a
+// terminal and NO_COLOR must not be set.
+func useColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	_, ok := writerColumns(w)
+	return ok
+}
+
+// SetAutoWidth sets whether PrintDefaults and the default usage message wrap
+// flag usages to the width returned by TerminalWidth for the output.
+func (f *FlagSet) SetAutoWidth(autoWidth bool) {
+	f.autoWidth = autoWidth
+}
+
+// SetColor sets whether PrintDefaults and the default usage message colorize
+// flag names, types and defaults with ANSI escape sequences. Colors are only
+// used when the output is a terminal and the NO_COLOR environment variable
+// is not set.
+func (f *FlagSet) SetColor(color bool) {
+	f.color = color
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package pflag
+
+// terminalColumns returns the width of the terminal fd refers to, and false
+// if fd is not a terminal. Terminals are not detected on this platform.
+func terminalColumns(fd uintptr) (int, bool) {
+	return 0, false
+}
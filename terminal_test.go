@@ -0,0 +1,103 @@
+package pflag
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTerminalWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+
+	os.Setenv("COLUMNS", "57")
+	if w := TerminalWidth(new(bytes.Buffer)); w != 57 {
+		t.Errorf("expected the width from COLUMNS, got %d", w)
+	}
+
+	os.Setenv("COLUMNS", "")
+	if w := TerminalWidth(new(bytes.Buffer)); w != 0 {
+		t.Errorf("expected no width for a buffer, got %d", w)
+	}
+	file, err := ioutil.TempFile("", "pflag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if w := TerminalWidth(file); w != 0 {
+		t.Errorf("expected no width for a file, got %d", w)
+	}
+	if useColor(file) {
+		t.Error("expected no color for a file")
+	}
+}
+
+func TestAutoWidth(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+	os.Setenv("COLUMNS", "50")
+
+	f := NewFlagSet("test", ContinueOnError)
+	f.String("name", "", "a usage long enough to be wrapped to the width of the terminal")
+	buf := new(bytes.Buffer)
+	f.SetOutput(buf)
+
+	f.PrintDefaults()
+	if got := buf.String(); got != f.FlagUsagesWrapped(0) {
+		t.Errorf("expected no wrapping by default, got\n%s", got)
+	}
+
+	buf.Reset()
+	f.SetAutoWidth(true)
+	f.PrintDefaults()
+	if got := buf.String(); got != f.FlagUsagesWrapped(50) {
+		t.Errorf("expected usages wrapped to 50 columns, got\n%s", got)
+	}
+}
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestColor(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.StringP("listen", "l", ":80", "a usage long enough to be wrapped to the width of the terminal")
+	f.Int("workers", 4, "number of workers")
+	f.String("color", "", "colorize the output")
+	f.Lookup("color").NoOptDefVal = "auto"
+	var flags []*Flag
+	f.VisitAll(func(flag *Flag) {
+		flags = append(flags, flag)
+	})
+
+	colored := f.flagUsages(flags, 60, true)
+	for _, s := range []string{
+		"\x1b[1m-l\x1b[0m, \x1b[1m--listen\x1b[0m \x1b[36mstring\x1b[0m",
+		"(default \x1b[32m4\x1b[0m)",
+		"\x1b[32m[=\"auto\"]\x1b[0m",
+	} {
+		if !strings.Contains(colored, s) {
+			t.Errorf("expected %q in\n%s", s, colored)
+		}
+	}
+	if plain := f.FlagUsagesWrapped(60); escapes.ReplaceAllString(colored, "") != plain {
+		t.Errorf("expected colors not to change the layout\n%s\ngot\n%s", plain, escapes.ReplaceAllString(colored, ""))
+	}
+
+	// The output of the tests is not a terminal.
+	buf := new(bytes.Buffer)
+	f.SetOutput(buf)
+	f.SetColor(true)
+	f.PrintDefaults()
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("expected no colors when the output is not a terminal, got %q", buf)
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Setenv("NO_COLOR", "1")
+	if useColor(os.Stdout) {
+		t.Error("expected NO_COLOR to disable colors")
+	}
+}
@@ -38,6 +38,11 @@ func (s *uintSliceValue) Set(val string) error {
 	return nil
 }
 
+func (s *uintSliceValue) save() func() {
+	value, changed := *s.value, s.changed
+	return func() { *s.value, s.changed = value, changed }
+}
+
 func (s *uintSliceValue) Type() string {
 	return "uintSlice"
 }
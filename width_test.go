@@ -0,0 +1,106 @@
+package pflag
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	testCases := []struct {
+		s     string
+		width int
+	}{
+		{"", 0},
+		{"port", 4},
+		{"café", 4},
+		{"café", 4},
+		{"日本語", 6},
+		{"ｆｕｌｌ", 8},
+		{"한국어", 6},
+		{"🚀 go", 5},
+		{"👩‍💻", 4},
+		{"\x1b[1m--name\x1b[0m", 6},
+	}
+	for _, tc := range testCases {
+		if w := displayWidth(tc.s); w != tc.width {
+			t.Errorf("expected width %d for %q, got %d", tc.width, tc.s, w)
+		}
+	}
+}
+
+func TestWrapUnicode(t *testing.T) {
+	testCases := []struct {
+		s        string
+		expected string
+	}{
+		{
+			"サーバーが待ち受けるアドレスとポート番号を指定します。省略した場合は既定値が使われます。",
+			"サーバーが待ち受けるアドレスとポート番号を指\n" +
+				"    定します。省略した場合は既定値が使われます。",
+		},
+		{
+			"あいうえおかきくけこさしすせそたちつてとなに。ぬねのはひふへほまみむめもやゆよ",
+			"あいうえおかきくけこさしすせそたちつてとな\n" +
+				"    に。ぬねのはひふへほまみむめもやゆよ",
+		},
+		{
+			"使用するキャッシュ ディレクトリ。",
+			"使用するキャッシュ ディレクトリ。",
+		},
+		{
+			"Größe des Zwischenspeichers für übertragene Dateien in Mebibytes",
+			"Größe des Zwischenspeichers für übertragene\n" +
+				"    Dateien in Mebibytes",
+		},
+		{
+			"🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀",
+			"🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀🚀\n" +
+				"    🚀🚀🚀🚀🚀🚀🚀",
+		},
+		{
+			"one of:\n  fast   trades accuracy for a shorter run time, which is fine for tests\n  exact\n\nwhere fast is the default",
+			"one of:\n" +
+				"      fast   trades accuracy for a shorter run\n" +
+				"      time, which is fine for tests\n" +
+				"      exact\n" +
+				"\n" +
+				"    where fast is the default",
+		},
+	}
+	for _, tc := range testCases {
+		got := wrap(4, 54, tc.s)
+		if got != tc.expected {
+			t.Errorf("expected\n%s\ngot\n%s", tc.expected, got)
+		}
+		for _, line := range strings.Split(got, "\n") {
+			if !utf8.ValidString(line) {
+				t.Errorf("expected no rune to be split, got %q", line)
+			}
+		}
+	}
+}
+
+func TestWrapNewlinesWithoutWrapping(t *testing.T) {
+	expected := "first line\n    second line\n\n    after a blank line"
+	if got := wrap(4, 0, "first line\nsecond line\n\nafter a blank line"); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestMultilingualUsages(t *testing.T) {
+	f := NewFlagSet("test", ContinueOnError)
+	f.SortFlags = false
+	f.StringP("listen", "l", "", "待ち受ける`アドレス`")
+	f.String("taille", "", "taille du tampon en octets")
+	f.Bool("verbose", false, "详细输出\n每个请求打印一行")
+
+	expected := `  -l, --listen アドレス   待ち受けるアドレス
+      --taille string     taille du tampon en octets
+      --verbose           详细输出
+                          每个请求打印一行
+`
+	if got := f.FlagUsages(); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+}